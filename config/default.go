@@ -24,6 +24,11 @@ webserver:
   # not be an empty string.
   password: PleaseChange123
   
+quiz:
+  # Questions reported by at least this many distinct viewers, with the reports triaged by an
+  # editor, are not used in new games anymore. 0 disables the exclusion.
+  report_threshold: 0
+
 mysql:
   user: user
   password: password
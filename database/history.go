@@ -0,0 +1,64 @@
+package database
+
+import (
+	"strings"
+	"time"
+)
+
+// QuestionHistoryEntry is a question that was asked in a game of a user. The entries are stored in
+// the table question_history with the columns user_id, question_id, game_id and asked_at.
+type QuestionHistoryEntry struct {
+	QuestionID string
+	GameID     string
+	AskedAt    time.Time
+}
+
+// GetQuestionHistory returns all questions that were asked in games of the user with the given ID,
+// starting with the most recent one.
+func GetQuestionHistory(userID string) ([]QuestionHistoryEntry, error) {
+	rows, err := Query(`SELECT question_id,game_id,asked_at
+		FROM question_history
+		WHERE user_id=?
+		ORDER BY asked_at DESC;`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []QuestionHistoryEntry
+	for rows.Next() {
+		var entry QuestionHistoryEntry
+		err = rows.Scan(&entry.QuestionID, &entry.GameID, &entry.AskedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+	return history, rows.Err()
+}
+
+// AddQuestionHistory saves that the questions with the given IDs were asked in the game with the
+// given ID of a user.
+func AddQuestionHistory(userID, gameID string, questionIDs ...string) error {
+	if len(questionIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	values := make([]string, 0, len(questionIDs))
+	args := make([]any, 0, 4*len(questionIDs))
+	for _, questionID := range questionIDs {
+		values = append(values, "(?,?,?,?)")
+		args = append(args, userID, questionID, gameID, now)
+	}
+	_, err := Exec(`INSERT INTO question_history (user_id,question_id,game_id,asked_at)
+		VALUES `+strings.Join(values, ",")+`;`,
+		args...)
+	return err
+}
+
+// ResetQuestionHistory deletes the whole question history of the user with the given ID.
+func ResetQuestionHistory(userID string) error {
+	_, err := Exec(`DELETE FROM question_history WHERE user_id=?;`, userID)
+	return err
+}
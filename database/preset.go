@@ -0,0 +1,92 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Preset is a saved game setup of a user. They are stored in the table game_presets with the
+// columns id, user_id, name, settings and update_time.
+type Preset struct {
+	ID        string
+	Name      string
+	Settings  []byte
+	UpdatedAt time.Time
+}
+
+// GetPresets returns all presets of the user with the given ID, sorted by name.
+func GetPresets(userID string) ([]Preset, error) {
+	rows, err := Query(`SELECT id,name,settings,update_time
+		FROM game_presets
+		WHERE user_id=?
+		ORDER BY name;`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var presets []Preset
+	for rows.Next() {
+		var p Preset
+		err = rows.Scan(&p.ID, &p.Name, &p.Settings, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		presets = append(presets, p)
+	}
+	return presets, rows.Err()
+}
+
+// GetPreset returns the preset with the given ID of the user with the given ID. If there is no such
+// preset GetPreset returns nil.
+func GetPreset(userID, presetID string) (*Preset, error) {
+	var p Preset
+	err := QueryRow(`SELECT id,name,settings,update_time
+		FROM game_presets
+		WHERE user_id=? AND id=?;`,
+		userID, presetID).
+		Scan(&p.ID, &p.Name, &p.Settings, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePreset saves a new preset for the user with the given ID and returns the ID of the preset.
+func CreatePreset(userID, name string, settings []byte) (presetID string, err error) {
+	presetID = uuid.New().String()
+	_, err = Exec(`INSERT INTO game_presets (id,user_id,name,settings,update_time)
+		VALUES (?,?,?,?,?);`,
+		presetID, userID, name, settings, time.Now())
+	return presetID, err
+}
+
+// UpdatePreset replaces name and settings of an existing preset of the user with the given ID. It
+// reports whether the preset was found.
+func UpdatePreset(userID, presetID, name string, settings []byte) (found bool, err error) {
+	result, err := Exec(`UPDATE game_presets
+		SET name=?, settings=?, update_time=?
+		WHERE user_id=? AND id=?;`,
+		name, settings, time.Now(), userID, presetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeletePreset deletes a preset of the user with the given ID. It reports whether the preset was
+// found.
+func DeletePreset(userID, presetID string) (found bool, err error) {
+	result, err := Exec(`DELETE FROM game_presets WHERE user_id=? AND id=?;`, userID, presetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report is a question flagged as wrong during a game. They are stored in the table
+// question_reports with the columns id, question_id, question, category_id, round, user_id,
+// reporter, comment, status, note, create_time and update_time. UserID is the streamer of the
+// game, Reporter the viewer or channel that sent the report.
+type Report struct {
+	ID         string
+	QuestionID string
+	Question   string
+	CategoryID string
+	Round      int
+	UserID     string
+	Reporter   string
+	Comment    string
+	Status     string
+	Note       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// AddReport saves r as new report with the given status and returns its ID.
+func AddReport(r Report) (reportID string, err error) {
+	reportID = uuid.New().String()
+	now := time.Now()
+	_, err = Exec(`INSERT INTO question_reports
+		(id,question_id,question,category_id,round,user_id,reporter,comment,status,note,create_time,update_time)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?);`,
+		reportID, r.QuestionID, r.Question, r.CategoryID, r.Round, r.UserID, r.Reporter, r.Comment, r.Status, r.Note, now, now)
+	return reportID, err
+}
+
+// GetReports returns all reports with one of the given statuses, or all reports if no status is
+// given, starting with the most recent one.
+func GetReports(statuses ...string) ([]Report, error) {
+	query := `SELECT id,question_id,question,category_id,round,user_id,reporter,comment,status,note,create_time,update_time
+		FROM question_reports`
+	args := make([]any, 0, len(statuses))
+	if len(statuses) > 0 {
+		query += ` WHERE status IN (?` + strings.Repeat(",?", len(statuses)-1) + `)`
+		for _, s := range statuses {
+			args = append(args, s)
+		}
+	}
+	rows, err := Query(query+` ORDER BY create_time DESC;`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []Report
+	for rows.Next() {
+		var r Report
+		err = rows.Scan(&r.ID, &r.QuestionID, &r.Question, &r.CategoryID, &r.Round, &r.UserID, &r.Reporter, &r.Comment, &r.Status, &r.Note, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// GetReport returns the report with the given ID. If there is no such report GetReport returns nil.
+func GetReport(reportID string) (*Report, error) {
+	var r Report
+	err := QueryRow(`SELECT id,question_id,question,category_id,round,user_id,reporter,comment,status,note,create_time,update_time
+		FROM question_reports
+		WHERE id=?;`,
+		reportID).
+		Scan(&r.ID, &r.QuestionID, &r.Question, &r.CategoryID, &r.Round, &r.UserID, &r.Reporter, &r.Comment, &r.Status, &r.Note, &r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UpdateReport sets status and note of the report with the given ID. It reports whether the report
+// was found.
+func UpdateReport(reportID, status, note string) (found bool, err error) {
+	result, err := Exec(`UPDATE question_reports
+		SET status=?, note=?, update_time=?
+		WHERE id=?;`,
+		status, note, time.Now(), reportID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// CountReports returns the amount of distinct reporters with a report with one of the given
+// statuses for each question ID. Questions without such reports are not included.
+func CountReports(statuses ...string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if len(statuses) == 0 {
+		return counts, nil
+	}
+	args := make([]any, 0, len(statuses))
+	for _, s := range statuses {
+		args = append(args, s)
+	}
+	rows, err := Query(`SELECT question_id,COUNT(DISTINCT LOWER(reporter))
+		FROM question_reports
+		WHERE status IN (?`+strings.Repeat(",?", len(statuses)-1)+`)
+		GROUP BY question_id;`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var questionID string
+		var n int
+		err = rows.Scan(&questionID, &n)
+		if err != nil {
+			return nil, err
+		}
+		counts[questionID] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UserSettings are the settings of a user that are kept between sessions. They are stored in the
+// table user_settings with the columns user_id, stream_delay_ms and update_time.
+type UserSettings struct {
+	// StreamDelay is the broadcast latency of the streamer
+	StreamDelay time.Duration
+}
+
+// GetUserSettings returns the settings of the user with the given ID. If the user never saved any
+// settings GetUserSettings returns nil.
+func GetUserSettings(userID string) (*UserSettings, error) {
+	var delay int64
+	err := QueryRow(`SELECT stream_delay_ms
+		FROM user_settings
+		WHERE user_id=?;`,
+		userID).
+		Scan(&delay)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &UserSettings{
+		StreamDelay: time.Duration(delay) * time.Millisecond,
+	}, nil
+}
+
+// SaveUserSettings saves s as the settings of the user with the given ID.
+func SaveUserSettings(userID string, s UserSettings) error {
+	delay := s.StreamDelay.Milliseconds()
+	now := time.Now()
+	_, err := Exec(`INSERT INTO user_settings (user_id,stream_delay_ms,update_time)
+		VALUES (?,?,?)
+		ON DUPLICATE KEY UPDATE stream_delay_ms=?, update_time=?;`,
+		userID, delay, now, delay, now)
+	return err
+}
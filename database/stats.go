@@ -0,0 +1,60 @@
+package database
+
+import "strings"
+
+// QuestionStats are the measured results of a question over all games. They are stored in the
+// table question_stats with the columns question_id, asked and correct.
+type QuestionStats struct {
+	// Asked is the amount of rounds the question was asked in
+	Asked int
+	// Correct is the amount of those rounds chat answered correctly
+	Correct int
+}
+
+// GetQuestionStats returns the stats of the questions with the given IDs. Questions that were never
+// asked are missing in the result.
+func GetQuestionStats(questionIDs ...string) (map[string]QuestionStats, error) {
+	stats := make(map[string]QuestionStats, len(questionIDs))
+	if len(questionIDs) == 0 {
+		return stats, nil
+	}
+	args := make([]any, len(questionIDs))
+	for i, questionID := range questionIDs {
+		args[i] = questionID
+	}
+	rows, err := Query(`SELECT question_id,asked,correct
+		FROM question_stats
+		WHERE question_id IN (?`+strings.Repeat(",?", len(questionIDs)-1)+`);`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			questionID string
+			s          QuestionStats
+		)
+		err = rows.Scan(&questionID, &s.Asked, &s.Correct)
+		if err != nil {
+			return nil, err
+		}
+		stats[questionID] = s
+	}
+	return stats, rows.Err()
+}
+
+// AddQuestionResult counts a round of the question with the given ID and whether chat answered it
+// correctly.
+func AddQuestionResult(questionID string, correct bool) error {
+	var c int
+	if correct {
+		c = 1
+	}
+	_, err := Exec(`INSERT INTO question_stats (question_id,asked,correct)
+		VALUES (?,1,?)
+		ON DUPLICATE KEY UPDATE asked=asked+1, correct=correct+?;`,
+		questionID, c, c)
+	return err
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Submission is a question submitted by the community. They are stored in the table
+// question_submissions with the columns id, type, question, correct, wrong, category_id,
+// submitter, channel, status, note, create_time and update_time. Correct and Wrong are json encoded
+// lists of answers, Channel is the channel the question was submitted in.
+type Submission struct {
+	ID         string
+	Type       string
+	Question   string
+	Correct    []byte
+	Wrong      []byte
+	CategoryID string
+	Submitter  string
+	Channel    string
+	Status     string
+	Note       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// AddSubmission saves s as new submission and returns its ID.
+func AddSubmission(s Submission) (submissionID string, err error) {
+	submissionID = uuid.New().String()
+	now := time.Now()
+	_, err = Exec(`INSERT INTO question_submissions
+		(id,type,question,correct,wrong,category_id,submitter,channel,status,note,create_time,update_time)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?);`,
+		submissionID, s.Type, s.Question, s.Correct, s.Wrong, s.CategoryID, s.Submitter, s.Channel, s.Status, s.Note, now, now)
+	return submissionID, err
+}
+
+// GetSubmissions returns all submissions with one of the given statuses, or all submissions if no
+// status is given, starting with the oldest one.
+func GetSubmissions(statuses ...string) ([]Submission, error) {
+	query := `SELECT id,type,question,correct,wrong,category_id,submitter,channel,status,note,create_time,update_time
+		FROM question_submissions`
+	args := make([]any, 0, len(statuses))
+	if len(statuses) > 0 {
+		query += ` WHERE status IN (?` + strings.Repeat(",?", len(statuses)-1) + `)`
+		for _, s := range statuses {
+			args = append(args, s)
+		}
+	}
+	rows, err := Query(query+` ORDER BY create_time;`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var submissions []Submission
+	for rows.Next() {
+		var s Submission
+		err = rows.Scan(&s.ID, &s.Type, &s.Question, &s.Correct, &s.Wrong, &s.CategoryID, &s.Submitter, &s.Channel, &s.Status, &s.Note, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, s)
+	}
+	return submissions, rows.Err()
+}
+
+// GetSubmission returns the submission with the given ID. If there is no such submission
+// GetSubmission returns nil.
+func GetSubmission(submissionID string) (*Submission, error) {
+	var s Submission
+	err := QueryRow(`SELECT id,type,question,correct,wrong,category_id,submitter,channel,status,note,create_time,update_time
+		FROM question_submissions
+		WHERE id=?;`,
+		submissionID).
+		Scan(&s.ID, &s.Type, &s.Question, &s.Correct, &s.Wrong, &s.CategoryID, &s.Submitter, &s.Channel, &s.Status, &s.Note, &s.CreatedAt, &s.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UpdateSubmission replaces type, question, answers, category, status and note of the submission
+// with the ID of s. It reports whether the submission was found.
+func UpdateSubmission(s Submission) (found bool, err error) {
+	result, err := Exec(`UPDATE question_submissions
+		SET type=?, question=?, correct=?, wrong=?, category_id=?, status=?, note=?, update_time=?
+		WHERE id=?;`,
+		s.Type, s.Question, s.Correct, s.Wrong, s.CategoryID, s.Status, s.Note, time.Now(), s.ID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -3,9 +3,15 @@ package quiz
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"math/rand"
+	"slices"
+	"sync"
 	"time"
 
+	"quiz_backend/database"
+
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/kesuaheli/twitchgo"
 )
@@ -14,17 +20,25 @@ import (
 type Connection struct {
 	Twitch       *twitchgo.Session
 	WS           *websocket.Conn
+	wsMu         sync.Mutex
 	lastResponse time.Time
 
 	started time.Time
 	Game    *Game
+	// StreamDelay is the broadcast latency of the streamer. It is used to move the time window for
+	// chat votes. It is saved with [Connection.SetStreamDelay] and loaded again by [New].
+	StreamDelay time.Duration
 
 	userID string
+	// channel is the Twitch channel of the player
+	channel string
 }
 
 type wsVoteMessage struct {
 	Type     string `json:"type"`
 	Username string `json:"username"`
+	Changed  bool   `json:"changed,omitempty"`
+	Team     string `json:"team,omitempty"`
 }
 
 // AllConnections is a map of a user id to connection for all currently active connections
@@ -43,6 +57,13 @@ func New(userID string) (c *Connection) {
 		userID:  userID,
 		started: time.Now(),
 	}
+	settings, err := database.GetUserSettings(userID)
+	if err != nil {
+		// the streamer can still set the delay again
+		log.Printf("Error loading settings of user %s: %v", userID, err)
+	} else if settings != nil {
+		c.StreamDelay = settings.StreamDelay
+	}
 
 	AllConnections[userID] = c
 	return c
@@ -55,6 +76,16 @@ func GetConnection(userID string) (*Connection, bool) {
 	return c, ok
 }
 
+// SetStreamDelay sets the broadcast latency of the streamer and saves it for later sessions.
+func (c *Connection) SetStreamDelay(delay time.Duration) error {
+	c.StreamDelay = delay
+	err := database.SaveUserSettings(c.userID, database.UserSettings{StreamDelay: delay})
+	if err != nil {
+		return fmt.Errorf("save stream delay: %v", err)
+	}
+	return nil
+}
+
 // SetLastResponse saves the current timestamp which can be reobtained as [time.Duration] by
 // [c.GetLastResponse].
 func (c *Connection) SetLastResponse() {
@@ -76,87 +107,295 @@ func (c *Connection) Close() {
 		c.Twitch.Close()
 		c.Twitch = nil
 	}
+	c.wsMu.Lock()
 	if c.WS != nil {
 		c.WS.Close()
 		c.WS = nil
 	}
-	if c.Game != nil && c.Game.RoundTimer != nil {
-		c.Game.RoundTimer.Stop()
+	c.wsMu.Unlock()
+	c.LeaveMatch()
+	if c.Game != nil {
+		c.Game.Stop()
+	}
+}
+
+// WriteJSON writes v as json message to the websocket of c. It is safe to be called concurrently.
+// If there is currently no websocket connected, WriteJSON does nothing.
+func (c *Connection) WriteJSON(v any) error {
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+	if c.WS == nil {
+		return nil
+	}
+	return c.WS.WriteJSON(v)
+}
+
+// sendEvent sends a websocket message of the given type to c. The fields of data are sent next to
+// the type in the same json object. data may be nil to send only the type.
+func (c *Connection) sendEvent(eventType string, data any) {
+	message := make(map[string]json.RawMessage)
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			log.Printf("Error marshaling websocket event '%s': %v", eventType, err)
+			return
+		}
+		err = json.Unmarshal(b, &message)
+		if err != nil {
+			log.Printf("Error websocket event '%s' is not a json object: %v", eventType, err)
+			return
+		}
+	}
+	message["type"], _ = json.Marshal(eventType)
+
+	err := c.WriteJSON(message)
+	if err != nil {
+		log.Printf("Error writing event '%s' to websocket: %v", eventType, err)
 	}
 }
 
 func (c *Connection) OnTwitchChannelMessage(t *twitchgo.Session, source *twitchgo.IRCUser, msg, msgID string, tags twitchgo.IRCMessageTags) {
-	if c.WS == nil || c.Game == nil {
+	if c.WS == nil {
 		return
 	}
-
-	vote := MsgToVote(msg, c.Game)
-	if vote == 0 {
-		// ignoring non-valid votes
+	if c.chatSubmit(source.Nickname, msg) {
 		return
 	}
-	if _, ok := c.Game.voteHistory[source.Nickname]; ok {
-		// ignore users who already voted
+	if c.Game == nil {
 		return
 	}
+	c.Game.mu.Lock()
+	eventType, data, vote := c.Game.chatMessage(source.Nickname, msg, tags)
+	c.Game.mu.Unlock()
+	if eventType == "" {
+		return
+	}
+
+	// the Twitch API and the websocket are not called with the game lock, so the game is not
+	// blocked by slow requests
+	if vote {
+		err := c.Twitch.DeleteMessage("", msgID)
+		if err != nil {
+			log.Printf("Failed to delete message: %v", err)
+		}
+	}
+	c.sendEvent(eventType, data)
+}
+
+// chatMessage handles the chat message msg of username as command or vote. It returns the
+// websocket event to send for it, or an empty type if msg is ignored. vote reports whether msg was
+// a vote, which is deleted from chat. The caller must hold g.mu.
+func (g *Game) chatMessage(username, msg string, tags twitchgo.IRCMessageTags) (eventType string, data any, vote bool) {
+	if event, ok := g.chatReport(username, msg); ok {
+		if event == nil {
+			return "", nil, false
+		}
+		return "QUESTION_REPORTED", *event, false
+	}
+	if team, ok := g.joinTeam(username, msg); ok {
+		return "TEAM_JOINED", struct {
+			Username string `json:"username"`
+			Team     string `json:"team"`
+			Members  int    `json:"members"`
+		}{
+			Username: username,
+			Team:     team,
+			Members:  g.teamCount(team),
+		}, false
+	}
 
 	v := wsVoteMessage{
 		Type:     "CHAT_VOTE",
-		Username: source.Nickname,
+		Username: username,
 	}
-
-	if tags.IsBroadcaster() {
-		if c.Game.StreamerVote != 0 {
-			// ignore streamer vote when already voted
-			return
+	now := time.Now()
+	if tags.IsBroadcaster() && g.streamerPlays() {
+		if !g.streamerVoteOpen(now) || g.streamerVote(msg, true) != nil {
+			// ignore invalid streamer votes or when already voted
+			return "", nil, false
 		}
-		c.Game.StreamerVote = vote
 		v.Type = "STREAMER_VOTE"
 	} else {
-		c.Game.voteHistory[source.Nickname] = true
-		c.Game.ChatVoteCount[vote-1]++
+		if !g.chatVoteOpen(now) {
+			// only accept votes while the question is visible on stream and the round is running
+			return "", nil, false
+		}
+		var ok bool
+		ok, v.Changed = g.chatVote(username, msg, g.VoteWeights.Weight(tags))
+		if !ok {
+			return "", nil, false
+		}
+		v.Team = g.teamMembers[username]
 	}
+	return v.Type, v, true
+}
 
-	err := c.Twitch.DeleteMessage("", msgID)
-	if err != nil {
-		log.Printf("Failed to delete message: %v", err)
-	}
+// GroupSettings selects the categories of a category group for a game. Categories maps category
+// IDs to the amount of rounds from that category, Random is the amount of rounds from random
+// categories of the group.
+type GroupSettings struct {
+	Random     int            `json:"random,omitempty"`
+	Categories map[string]int `json:"categories,omitempty"`
+}
 
-	err = c.WS.WriteJSON(v)
-	if err != nil {
-		log.Printf("Error writing chat vote to websocket: %v", err)
+// GameSettings are all settings to create a game with, as sent to [Connection.NewGame].
+type GameSettings struct {
+	Groups        map[string]GroupSettings `json:"groups"`
+	RoundDuration int                      `json:"round_duration"`
+	// CategoryDurations override RoundDuration in seconds for single categories
+	CategoryDurations map[string]int   `json:"category_durations,omitempty"`
+	AutoAdvance       bool             `json:"auto_advance"`
+	RevealDuration    int              `json:"reveal_duration"`
+	TextTolerance     *int             `json:"text_tolerance,omitempty"`
+	AnswerCount       int              `json:"answer_count,omitempty"`
+	Jokers            *Jokers          `json:"jokers,omitempty"`
+	VoteChange        VoteChangePolicy `json:"vote_change"`
+	MaxVoteChanges    int              `json:"max_vote_changes,omitempty"`
+	VoteWeights       VoteWeights      `json:"vote_weights"`
+	Consensus         Consensus        `json:"consensus"`
+	TeamMode          *TeamMode        `json:"team_mode,omitempty"`
+	Cooldown          QuestionCooldown `json:"question_cooldown"`
+	// Seed makes the rounds reproducible. Games with a seed ignore the question history, reports and
+	// measured difficulties of the streamer, see [GameSummary.Seed].
+	Seed       *int64       `json:"seed,omitempty"`
+	RoundOrder RoundOrder   `json:"round_order"`
+	Hints      HintSettings `json:"hints"`
+}
+
+// validate returns an error if one of the settings is invalid. Unset settings are set to their
+// defaults.
+func (s *GameSettings) validate() error {
+	if s.RoundDuration <= 0 {
+		return fmt.Errorf("round_duration must not be negative, got %ds", s.RoundDuration)
+	}
+	for categoryID, d := range s.CategoryDurations {
+		if d <= 0 {
+			return fmt.Errorf("category_durations must be positive, got %ds for '%s'", d, categoryID)
+		}
+	}
+	if s.AnswerCount == 0 {
+		s.AnswerCount = DefaultAnswerCount
+	}
+	if s.AnswerCount < MinAnswerCount || s.AnswerCount > MaxAnswerCount {
+		return fmt.Errorf("answer_count must be between %d and %d, got %d", MinAnswerCount, MaxAnswerCount, s.AnswerCount)
+	}
+	if s.VoteChange == VOTELIMITED && s.MaxVoteChanges <= 0 {
+		return fmt.Errorf("max_vote_changes must be positive for vote_change 'limited', got %d", s.MaxVoteChanges)
+	}
+	if err := s.VoteWeights.validate(); err != nil {
+		return err
+	}
+	if err := s.Consensus.validate(); err != nil {
+		return err
+	}
+	if err := s.Cooldown.validate(); err != nil {
+		return err
 	}
+	if err := s.Hints.validate(); err != nil {
+		return err
+	}
+	if s.TeamMode != nil {
+		if err := s.TeamMode.validate(); err != nil {
+			return err
+		}
+	}
+	if s.Jokers == nil {
+		s.Jokers = &Jokers{
+			FiftyFifty: defaultJokerCount,
+			AskChat:    defaultJokerCount,
+			Swap:       defaultJokerCount,
+		}
+	}
+	if s.Jokers.FiftyFifty < 0 || s.Jokers.AskChat < 0 || s.Jokers.Swap < 0 {
+		return fmt.Errorf("jokers must not be negative, got %+v", *s.Jokers)
+	}
+	if s.TextTolerance == nil {
+		textTolerance := defaultTextTolerance
+		s.TextTolerance = &textTolerance
+	}
+	if *s.TextTolerance < 0 {
+		return fmt.Errorf("text_tolerance must not be negative, got %d", *s.TextTolerance)
+	}
+	if s.AutoAdvance && s.RevealDuration <= 0 {
+		return fmt.Errorf("reveal_duration must be positive when auto_advance is set, got %ds", s.RevealDuration)
+	}
+	return nil
 }
 
+// NewGame creates a new game for c from the json encoded [GameSettings] in data. If data contains
+// the key "preset", the settings are loaded from the preset with that ID and all other keys in
+// data override single settings of the preset. Overrides replace the setting as a whole, e.g.
+// "groups" replaces all groups of the preset.
 func (c *Connection) NewGame(data []byte) error {
-	type groupData struct {
-		Random     int            `json:"random,omitempty"`
-		Categories map[string]int `json:"categories,omitempty"`
+	var presetData struct {
+		Preset string `json:"preset"`
 	}
-	var gameData struct {
-		Groups        map[string]groupData `json:"groups"`
-		RoundDuration int                  `json:"round_duration"`
+	err := json.Unmarshal(data, &presetData)
+	if err != nil {
+		return fmt.Errorf("create game: %v", err)
+	}
+	var gameData GameSettings
+	if presetData.Preset != "" {
+		preset, err := c.GetPreset(presetData.Preset)
+		if err != nil {
+			return fmt.Errorf("create game: %w", err)
+		}
+		preset.Settings.removeMissingCategories()
+		data, err = preset.Settings.override(data)
+		if err != nil {
+			return fmt.Errorf("create game: %v", err)
+		}
 	}
-	err := json.Unmarshal(data, &gameData)
+	err = json.Unmarshal(data, &gameData)
 	if err != nil {
 		return fmt.Errorf("create game: %v", err)
 	}
+	if err = gameData.validate(); err != nil {
+		return fmt.Errorf("create game: %v", err)
+	}
 
-	if gameData.RoundDuration <= 0 {
-		return fmt.Errorf("create game: round_duration must not be negative, got %ds", gameData.RoundDuration)
+	seed := rand.Int63()
+	history := questionHistory{}
+	if gameData.Seed != nil {
+		// the history, reports and measured difficulties would change the rounds of a rematch
+		seed = *gameData.Seed
+	} else {
+		history, err = loadQuestionHistory(c.userID, gameData.Cooldown)
+		if err != nil {
+			// a game with repeated questions is still better than no game
+			log.Printf("Error creating game for user %s: %v", c.userID, err)
+		}
+		if err = history.excludeReported(); err != nil {
+			// reported questions are still better than no game
+			log.Printf("Error creating game for user %s: %v", c.userID, err)
+		}
+	}
+	rng := rand.New(rand.NewSource(seed))
+	summary := &GameSummary{}
+	if gameData.TeamMode != nil {
+		summary.Teams = make(map[string]*TeamSummary, len(gameData.TeamMode.Teams))
+		for _, team := range gameData.TeamMode.Teams {
+			summary.Teams[team] = &TeamSummary{}
+		}
 	}
 
+	// maps are iterated in sorted order, so the rounds only depend on the seed
 	var rounds []*Round
-	for groupID, group := range gameData.Groups {
-		Categories.ShuffleCategories(groupID, group.Random, group.Categories)
+	for _, groupID := range slices.Sorted(maps.Keys(gameData.Groups)) {
+		group := gameData.Groups[groupID]
+		if group.Categories == nil {
+			group.Categories = make(map[string]int)
+		}
+		Categories.ShuffleCategories(rng, groupID, group.Random, group.Categories)
 
-		for categoryID, amount := range group.Categories {
+		for _, categoryID := range slices.Sorted(maps.Keys(group.Categories)) {
+			amount := group.Categories[categoryID]
 			category := Categories.GetCategoryByID(categoryID)
 			if category.ID == "" {
 				return fmt.Errorf("create game: unknown category '%s'", categoryID)
 			}
 
-			newRounds := category.GetRounds(amount)
+			newRounds := category.GetRounds(rng, amount, gameData.AnswerCount, history)
 			for _, r := range newRounds {
 				r.Group = Categories.GetGroupByID(groupID).GetDefinition()
 				r.Group.Categories = nil
@@ -169,21 +408,59 @@ func (c *Connection) NewGame(data []byte) error {
 		return fmt.Errorf("create game: too few question")
 	}
 
-	rand.Shuffle(max, func(i, j int) {
-		rounds[i], rounds[j] = rounds[j], rounds[i]
-	})
+	var usedMeasured bool
+	if gameData.RoundOrder == ROUNDORDERDIFFICULTY {
+		usedMeasured, err = setDifficulty(rounds, gameData.Seed == nil)
+		if err != nil {
+			// without measured difficulties only the ones from the sheet are used
+			log.Printf("Error creating game for user %s: %v", c.userID, err)
+		}
+	}
+	gameData.RoundOrder.arrange(rng, rounds)
+	// a game created with the seed ignores the history, reports and measured difficulties, so the
+	// seed is only shown if they did not change the rounds
+	if len(history.seen) == 0 && len(history.blocked) == 0 && !usedMeasured {
+		summary.Seed = &seed
+	}
 
 	for i, r := range rounds {
 		r.Current = i + 1
 		r.Max = max
 	}
 
+	categoryDurations := make(map[string]time.Duration, len(gameData.CategoryDurations))
+	for categoryID, d := range gameData.CategoryDurations {
+		categoryDurations[categoryID] = time.Duration(d) * time.Second
+	}
+
+	c.LeaveMatch()
+	if c.Game != nil {
+		c.Game.Stop()
+	}
 	c.Game = &Game{
-		connection:    c,
-		Rounds:        rounds,
-		RoundDuration: time.Duration(gameData.RoundDuration) * time.Second,
-		Summary:       &GameSummary{},
-		voteHistory:   make(map[string]bool),
+		id:                uuid.NewString(),
+		connection:        c,
+		Rounds:            rounds,
+		RoundDuration:     time.Duration(gameData.RoundDuration) * time.Second,
+		CategoryDurations: categoryDurations,
+		AutoAdvance:       gameData.AutoAdvance,
+		RevealDuration:    time.Duration(gameData.RevealDuration) * time.Second,
+		TextTolerance:     *gameData.TextTolerance,
+		AnswerCount:       gameData.AnswerCount,
+		Jokers:            *gameData.Jokers,
+
+		VoteChangePolicy: gameData.VoteChange,
+		MaxVoteChanges:   gameData.MaxVoteChanges,
+		VoteWeights:      gameData.VoteWeights,
+		Consensus:        gameData.Consensus,
+		TeamMode:         gameData.TeamMode,
+		Hints:            gameData.Hints,
+		Summary:          summary,
+		seed:             seed,
+		rng:              rng,
+		history:          history,
+		chatVotes:        make(map[string]viewerVote),
+		teamMembers:      make(map[string]string),
 	}
 
 	return nil
@@ -0,0 +1,184 @@
+package quiz
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// TiePolicy defines what happens when several answers have the most chat votes.
+type TiePolicy uint8
+
+const (
+	// TIENONE lets chat commit to no answer on a tie. This is the default.
+	TIENONE TiePolicy = iota
+	// TIERANDOM picks one of the tied answers at random.
+	TIERANDOM
+	// TIESPLIT splits the round points between the tied answers. Chat gets the share of the correct
+	// answer if it is one of them.
+	TIESPLIT
+)
+
+func (p TiePolicy) String() string {
+	switch p {
+	case TIENONE:
+		return "none"
+	case TIERANDOM:
+		return "random"
+	case TIESPLIT:
+		return "split"
+	default:
+		return fmt.Sprintf("TiePolicy(%d)", p)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The policy is encoded as its string representation.
+func (p TiePolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. An empty string results in [TIENONE].
+func (p *TiePolicy) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	switch s {
+	case "", "none":
+		*p = TIENONE
+	case "random":
+		*p = TIERANDOM
+	case "split":
+		*p = TIESPLIT
+	default:
+		return fmt.Errorf("unknown tie policy '%s'", s)
+	}
+	return nil
+}
+
+// ChatDecision is the rule that decided the answer of chat in a round.
+type ChatDecision uint8
+
+const (
+	// DECISIONNONE means the round was not decided by a chat vote, e.g. a free text round.
+	DECISIONNONE ChatDecision = iota
+	// DECISIONNOVOTES means nobody in chat voted.
+	DECISIONNOVOTES
+	// DECISIONMINVOTERS means less viewers than [Consensus.MinVoters] voted, so chat did not commit.
+	DECISIONMINVOTERS
+	// DECISIONMINMAJORITY means the leading answer did not reach [Consensus.MinMajority], so chat
+	// did not commit.
+	DECISIONMINMAJORITY
+	// DECISIONPLURALITY means a single answer had the most votes.
+	DECISIONPLURALITY
+	// DECISIONTIENONE, DECISIONTIERANDOM and DECISIONTIESPLIT mean several answers had the most
+	// votes and the tie was resolved by the according [TiePolicy].
+	DECISIONTIENONE
+	DECISIONTIERANDOM
+	DECISIONTIESPLIT
+)
+
+func (d ChatDecision) String() string {
+	switch d {
+	case DECISIONNONE:
+		return "none"
+	case DECISIONNOVOTES:
+		return "no_votes"
+	case DECISIONMINVOTERS:
+		return "min_voters"
+	case DECISIONMINMAJORITY:
+		return "min_majority"
+	case DECISIONPLURALITY:
+		return "plurality"
+	case DECISIONTIENONE:
+		return "tie_none"
+	case DECISIONTIERANDOM:
+		return "tie_random"
+	case DECISIONTIESPLIT:
+		return "tie_split"
+	default:
+		return fmt.Sprintf("ChatDecision(%d)", d)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The decision is encoded as its string representation.
+func (d ChatDecision) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Consensus are the rules for chat to commit to an answer in multiple choice rounds. The zero value
+// commits to the answer with the most votes as soon as anyone voted, and to no answer on ties.
+type Consensus struct {
+	// MinVoters is the minimum amount of viewers that have to vote. It applies to "select all that
+	// apply" rounds as well.
+	MinVoters int `json:"min_voters"`
+	// MinMajority is the minimum share of the votes in percent the leading answer needs.
+	MinMajority float64   `json:"min_majority"`
+	Tie         TiePolicy `json:"tie"`
+}
+
+// validate returns an error if one of the rules is out of range.
+func (c Consensus) validate() error {
+	if c.MinVoters < 0 {
+		return fmt.Errorf("consensus min_voters must not be negative, got %d", c.MinVoters)
+	}
+	if c.MinMajority < 0 || c.MinMajority > 100 {
+		return fmt.Errorf("consensus min_majority must be between 0 and 100, got %g", c.MinMajority)
+	}
+	return nil
+}
+
+// decideChoice determines the answer of chat from the votes per answer in tally and the points chat
+// gets for it. The answer is 0 if chat did not commit to one. On a tie, tied lists the tied answers
+// (indexed 1). pick chooses the answer among the tied ones with [TIERANDOM].
+func (c Consensus) decideChoice(pick func(tied []int) int, tally []float64, voters, correct int) (answer int, tied []int, points int, decision ChatDecision) {
+	if voters == 0 {
+		return 0, nil, 0, DECISIONNOVOTES
+	}
+	if voters < c.MinVoters {
+		return 0, nil, 0, DECISIONMINVOTERS
+	}
+
+	var total, most float64
+	for _, votes := range tally {
+		total += votes
+		most = max(most, votes)
+	}
+	if total <= 0 {
+		return 0, nil, 0, DECISIONNOVOTES
+	}
+	if most/total*100 < c.MinMajority {
+		return 0, nil, 0, DECISIONMINMAJORITY
+	}
+	for i, votes := range tally {
+		if votes == most {
+			tied = append(tied, i+1)
+		}
+	}
+	if len(tied) == 1 {
+		return tied[0], nil, choicePoints(tied[0], correct), DECISIONPLURALITY
+	}
+
+	switch c.Tie {
+	case TIERANDOM:
+		answer = pick(tied)
+		return answer, tied, choicePoints(answer, correct), DECISIONTIERANDOM
+	case TIESPLIT:
+		for _, a := range tied {
+			if a == correct {
+				points = int(math.Round(float64(roundPoints) / float64(len(tied))))
+			}
+		}
+		return 0, tied, points, DECISIONTIESPLIT
+	default:
+		return 0, tied, 0, DECISIONTIENONE
+	}
+}
+
+// choicePoints returns the points for answer in a multiple choice round.
+func choicePoints(answer, correct int) int {
+	if answer == correct {
+		return roundPoints
+	}
+	return 0
+}
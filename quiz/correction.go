@@ -0,0 +1,278 @@
+package quiz
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CorrectionAction is a fix the streamer applies to the current round when its question turns out
+// to be wrong.
+type CorrectionAction uint8
+
+const (
+	// CORRECTIONVOID voids the round, nobody gets points for it.
+	CORRECTIONVOID CorrectionAction = iota
+	// CORRECTIONREROLL replaces the question of the round with an unused one from the same category
+	// and starts the round again.
+	CORRECTIONREROLL
+	// CORRECTIONANSWER changes the correct answer of the round and scores it again.
+	CORRECTIONANSWER
+)
+
+func (a CorrectionAction) String() string {
+	switch a {
+	case CORRECTIONVOID:
+		return "void"
+	case CORRECTIONREROLL:
+		return "reroll"
+	case CORRECTIONANSWER:
+		return "correct"
+	default:
+		return fmt.Sprintf("CorrectionAction(%d)", a)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The action is encoded as its string representation.
+func (a CorrectionAction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (a *CorrectionAction) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	switch s {
+	case "void":
+		*a = CORRECTIONVOID
+	case "reroll":
+		*a = CORRECTIONREROLL
+	case "correct":
+		*a = CORRECTIONANSWER
+	default:
+		return fmt.Errorf("unknown correction action '%s'", s)
+	}
+	return nil
+}
+
+// RoundCorrection is a correction of a round, as recorded in [GameSummary.Corrections]. Question is
+// the question of the round before the correction, Answer the new correct answer for
+// [CORRECTIONANSWER].
+type RoundCorrection struct {
+	Round    int              `json:"round"`
+	Action   CorrectionAction `json:"action"`
+	Reason   string           `json:"reason"`
+	Question string           `json:"question"`
+	Answer   string           `json:"answer,omitempty"`
+	Time     time.Time        `json:"time"`
+}
+
+// correctionEvent is the payload of a ROUND_CORRECTED event. RoundSummary is only set if the answer
+// of the round is revealed.
+type correctionEvent struct {
+	RoundCorrection
+	Summary      GameSummary   `json:"summary"`
+	RoundSummary *RoundSummary `json:"round_summary,omitempty"`
+}
+
+// CorrectRound applies the correction action to round and recalculates the game summary. A reason
+// is required for every correction. answer is only used by [CORRECTIONANSWER] and parsed like a
+// streamer vote, e.g. "B" in multiple choice rounds or "CAB" in ordering rounds. Free text rounds
+// can't be corrected this way, because wrong chat answers are not kept.
+//
+// Only the current round can be corrected, until the next round is started. The votes of earlier
+// rounds are not kept, so they can't be scored again. A round of 0 means the current round.
+func (g *Game) CorrectRound(round int, action CorrectionAction, reason, answer string) (RoundCorrection, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkState("correct the round", STATEQUESTION, STATEVOTINGCLOSED, STATEREVEAL); err != nil {
+		return RoundCorrection{}, err
+	}
+	if round != 0 && round != g.Current {
+		return RoundCorrection{}, fmt.Errorf("%w: only the current round %d can be corrected, not round %d", ErrInvalidState, g.Current, round)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return RoundCorrection{}, fmt.Errorf("%w: a reason is required to correct a round", ErrInvalidArgument)
+	}
+
+	correction := RoundCorrection{
+		Round:    g.Current,
+		Action:   action,
+		Reason:   reason,
+		Question: g.round().Question,
+		Time:     time.Now(),
+	}
+	var err error
+	switch action {
+	case CORRECTIONVOID:
+		g.voidRound()
+	case CORRECTIONREROLL:
+		err = g.rerollRound()
+	case CORRECTIONANSWER:
+		correction.Answer = strings.TrimSpace(answer)
+		err = g.correctAnswer(correction.Answer)
+	default:
+		err = fmt.Errorf("%w: unknown correction action %s", ErrInvalidArgument, action)
+	}
+	if err != nil {
+		return RoundCorrection{}, err
+	}
+	g.Summary.Corrections = append(g.Summary.Corrections, correction)
+
+	event := correctionEvent{
+		RoundCorrection: correction,
+		Summary:         g.Summary.clone(),
+	}
+	if g.State == STATEREVEAL {
+		sum := g.roundSummary()
+		event.RoundSummary = &sum
+	}
+	g.connection.sendEvent("ROUND_CORRECTED", event)
+	if g.match != nil && g.State == STATEREVEAL {
+		g.match.report(g)
+	}
+	if action == CORRECTIONREROLL {
+		g.startRound()
+	}
+	return correction, nil
+}
+
+// voidRound takes back all points of the current round and reveals it, so the game can continue
+// with the next round. The caller must hold g.mu.
+func (g *Game) voidRound() {
+	g.stopRoundTimer()
+	g.stopHintTimer()
+	g.unscore()
+	g.paused = false
+	g.round().Voided = true
+
+	if g.State == STATEREVEAL {
+		return
+	}
+	g.State = STATEREVEAL
+	if g.AutoAdvance {
+		g.stopAdvanceTimer()
+		g.advanceTimer = time.AfterFunc(g.RevealDuration, g.autoAdvance)
+	}
+}
+
+// rerollRound takes back all points of the current round and replaces it with an unused question
+// from the same category. The caller must hold g.mu and start the new round.
+func (g *Game) rerollRound() error {
+	if g.match != nil {
+		return fmt.Errorf("%w: rounds cannot be rerolled in a match", ErrInvalidState)
+	}
+	if err := g.replaceRound(); err != nil {
+		return err
+	}
+	g.stopAdvanceTimer()
+	g.stopHintTimer()
+	g.unscore()
+	return nil
+}
+
+// correctAnswer sets answer as the correct answer of the current round and scores the round again
+// with the votes that were given. The caller must hold g.mu.
+func (g *Game) correctAnswer(answer string) error {
+	if err := g.checkState("correct the answer", STATEVOTINGCLOSED, STATEREVEAL); err != nil {
+		return err
+	}
+	round := g.round()
+	switch round.Type {
+	case QUESTIONTEXT:
+		return fmt.Errorf("%w: free text rounds cannot be corrected, void or reroll the round instead", ErrInvalidArgument)
+	case QUESTIONESTIMATE:
+		solution, ok := round.parseGuess(answer)
+		if !ok {
+			return fmt.Errorf("%w: '%s' is not a number", ErrInvalidArgument, answer)
+		}
+		round.Solution = &solution
+	case QUESTIONMULTI:
+		selection := MsgToSelection(answer, g)
+		if selection == 0 {
+			return fmt.Errorf("%w: '%s' is not a valid selection", ErrInvalidArgument, answer)
+		}
+		round.CorrectAll = selectionToList(selection)
+	case QUESTIONORDER, QUESTIONMATCH:
+		order := MsgToPermutation(answer, g)
+		if order == nil {
+			return fmt.Errorf("%w: '%s' is not a valid order of all answers", ErrInvalidArgument, answer)
+		}
+		round.CorrectOrder = order
+	default:
+		correct := MsgToVote(answer, g)
+		if correct == 0 {
+			return fmt.Errorf("%w: '%s' is not a valid answer", ErrInvalidArgument, answer)
+		}
+		round.Correct = correct
+	}
+
+	round.Voided = false
+	g.unscore()
+	g.score(round)
+	return nil
+}
+
+// unscore takes back the points of the current round, if it was scored already. The caller must
+// hold g.mu.
+func (g *Game) unscore() {
+	if g.State == STATEQUESTION {
+		return
+	}
+	g.Summary.subtract(g.roundScore)
+	g.roundScore = GameSummary{}
+}
+
+// clone returns a copy of s that does not share the team summaries.
+func (s *GameSummary) clone() GameSummary {
+	c := *s
+	if s.Teams != nil {
+		c.Teams = make(map[string]*TeamSummary, len(s.Teams))
+		for team, sum := range s.Teams {
+			teamSum := *sum
+			c.Teams[team] = &teamSum
+		}
+	}
+	c.Corrections = append([]RoundCorrection(nil), s.Corrections...)
+	return c
+}
+
+// since returns the points and won rounds s gained since before.
+func (s *GameSummary) since(before GameSummary) GameSummary {
+	d := GameSummary{
+		StreamerPoints: s.StreamerPoints - before.StreamerPoints,
+		StreamerWon:    s.StreamerWon - before.StreamerWon,
+		ChatPoints:     s.ChatPoints - before.ChatPoints,
+		ChatWon:        s.ChatWon - before.ChatWon,
+	}
+	if s.Teams != nil {
+		d.Teams = make(map[string]*TeamSummary, len(s.Teams))
+		for team, sum := range s.Teams {
+			teamSum := TeamSummary{Points: sum.Points, Won: sum.Won}
+			if b := before.Teams[team]; b != nil {
+				teamSum.Points -= b.Points
+				teamSum.Won -= b.Won
+			}
+			d.Teams[team] = &teamSum
+		}
+	}
+	return d
+}
+
+// subtract takes away the points and won rounds in d from s.
+func (s *GameSummary) subtract(d GameSummary) {
+	s.StreamerPoints -= d.StreamerPoints
+	s.StreamerWon -= d.StreamerWon
+	s.ChatPoints -= d.ChatPoints
+	s.ChatWon -= d.ChatWon
+	for team, teamSum := range d.Teams {
+		if sum := s.Teams[team]; sum != nil {
+			sum.Points -= teamSum.Points
+			sum.Won -= teamSum.Won
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package quiz
+
+import (
+	"cmp"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultEstimateTolerance is the relative error up to which an estimation still gets points if no
+// tolerance is set for the question.
+const defaultEstimateTolerance = 0.1
+
+// closestGuessCount is the number of closest viewers listed in the summary of an estimation round.
+const closestGuessCount = 3
+
+var numberRegex = regexp.MustCompile(`^\s*([-+]?[0-9][0-9.,' ]*)\s*(.*?)\s*$`)
+
+// EstimateGuess is a single guess of a viewer in an estimation round.
+type EstimateGuess struct {
+	Username string  `json:"username"`
+	Guess    float64 `json:"guess"`
+	// Error is the relative error of the guess to the solution.
+	Error float64 `json:"error"`
+}
+
+// EstimateSummary is the result of an estimation round.
+type EstimateSummary struct {
+	StreamerGuess *float64        `json:"streamer_guess"`
+	ChatMedian    *float64        `json:"chat_median"`
+	ChatGuesses   int             `json:"chat_guesses"`
+	Closest       []EstimateGuess `json:"closest"`
+}
+
+// ParseNumber parses a number from a chat message or a spreadsheet cell. It accepts both '.' and ','
+// as decimal separator and ignores thousands separators as well as a trailing unit, e.g.
+// "1.234,5 m", "1,234.5m" and "1234.5" all result in 1234.5. The unit is returned as well.
+func ParseNumber(s string) (number float64, unit string, ok bool) {
+	match := numberRegex.FindStringSubmatch(s)
+	if match == nil {
+		return 0, "", false
+	}
+	digits := strings.NewReplacer(" ", "", "'", "").Replace(match[1])
+	unit = match[2]
+
+	lastDot := strings.LastIndexByte(digits, '.')
+	lastComma := strings.LastIndexByte(digits, ',')
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// the separator that comes last is the decimal separator
+		if lastDot > lastComma {
+			digits = strings.ReplaceAll(digits, ",", "")
+		} else {
+			digits = strings.ReplaceAll(digits, ".", "")
+			digits = strings.Replace(digits, ",", ".", 1)
+		}
+	case strings.Count(digits, ".") > 1:
+		digits = strings.ReplaceAll(digits, ".", "")
+	case strings.Count(digits, ",") > 1:
+		digits = strings.ReplaceAll(digits, ",", "")
+	default:
+		digits = strings.Replace(digits, ",", ".", 1)
+	}
+
+	number, err := strconv.ParseFloat(digits, 64)
+	if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+		return 0, "", false
+	}
+	return number, unit, true
+}
+
+// parseGuess parses msg as guess for the estimation round r. A unit is optional, but if msg has one,
+// it has to match the unit of r. That way regular chat messages starting with a number are ignored.
+func (r *Round) parseGuess(msg string) (guess float64, ok bool) {
+	guess, unit, ok := ParseNumber(msg)
+	if !ok || unit != "" && !strings.EqualFold(unit, r.Unit) {
+		return 0, false
+	}
+	return guess, true
+}
+
+// parseTolerance parses a tolerance cell like "±10%" or "±5". A relative tolerance is returned as
+// fraction. An absolute tolerance is converted to a relative one using the solution.
+func parseTolerance(s string, solution float64) (tolerance float64, ok bool) {
+	s, ok = strings.CutPrefix(strings.TrimSpace(s), "±")
+	if !ok {
+		return 0, false
+	}
+	s, relative := strings.CutSuffix(strings.TrimSpace(s), "%")
+	value, _, ok := ParseNumber(s)
+	if !ok || value <= 0 {
+		return 0, false
+	}
+	if relative {
+		return value / 100, true
+	}
+	if solution == 0 {
+		return value, true
+	}
+	return value / math.Abs(solution), true
+}
+
+// estimateError returns the relative error of guess to solution. If the solution is 0 the absolute
+// error is returned instead.
+func estimateError(guess, solution float64) float64 {
+	if solution == 0 {
+		return math.Abs(guess)
+	}
+	return math.Abs(guess-solution) / math.Abs(solution)
+}
+
+// estimatePoints returns the points for a guess with the relative error err. An exact guess gets
+// full points, which decrease linearly up to the tolerance.
+func estimatePoints(err, tolerance float64) int {
+	if tolerance <= 0 || err > tolerance {
+		return 0
+	}
+	return int(math.Round(float64(roundPoints) * (1 - err/tolerance)))
+}
+
+// median returns the median of values. values must not be empty.
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// scoreEstimate scores the current estimation round for the streamer and chat. The caller must hold
+// g.mu.
+func (g *Game) scoreEstimate(round *Round) {
+	if round.Solution == nil {
+		return
+	}
+	solution := *round.Solution
+	sum := &EstimateSummary{
+		StreamerGuess: g.streamerGuess,
+		ChatGuesses:   len(g.chatGuesses),
+	}
+	g.estimateSummary = sum
+
+	if g.streamerGuess != nil {
+		err := estimateError(*g.streamerGuess, solution)
+		points := estimatePoints(err, round.Tolerance)
+		g.Summary.StreamerPoints += points
+		if err <= round.Tolerance {
+			g.Summary.StreamerWon++
+		}
+	}
+
+	if len(g.chatGuesses) == 0 {
+		return
+	}
+	guesses := make([]EstimateGuess, 0, len(g.chatGuesses))
+	values := make([]float64, 0, len(g.chatGuesses))
+	for username, guess := range g.chatGuesses {
+		guesses = append(guesses, EstimateGuess{
+			Username: username,
+			Guess:    guess,
+			Error:    estimateError(guess, solution),
+		})
+		values = append(values, guess)
+	}
+	slices.SortFunc(guesses, func(a, b EstimateGuess) int {
+		if a.Error != b.Error {
+			return cmp.Compare(a.Error, b.Error)
+		}
+		return strings.Compare(a.Username, b.Username)
+	})
+	sum.Closest = guesses[:min(len(guesses), closestGuessCount)]
+
+	chatMedian := median(values)
+	sum.ChatMedian = &chatMedian
+	err := estimateError(chatMedian, solution)
+	g.Summary.ChatPoints += estimatePoints(err, round.Tolerance)
+	if err <= round.Tolerance {
+		g.Summary.ChatWon++
+	}
+}
+
+// toEstimateRound converts q to an estimation round.
+func (q Question) toEstimateRound() Round {
+	solution := q.Solution
+	return Round{
+		Type:      QUESTIONESTIMATE,
+		Question:  q.Question.Text,
+		Answers:   []string{},
+		Solution:  &solution,
+		Unit:      q.Unit,
+		Tolerance: q.Tolerance,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -28,10 +29,16 @@ func FetchQuestions() (err error) {
 	lastFetch = time.Now()
 
 	log.Println("Getting Quiz from Google Spreadsheet...")
-	Categories, err = ParseFromGoogleSheets(viper.GetString("google.spreadsheetID"))
+	categories, err := ParseFromGoogleSheets(viper.GetString("google.spreadsheetID"))
 	if err != nil {
 		return err
 	}
+	err = categoryGroups(categories).addSubmissions()
+	if err != nil {
+		// the questions from the sheet can still be used
+		log.Printf("Error adding community submissions: %v", err)
+	}
+	Categories = categories
 
 	var categoryCount, questionCount, answerCountCorrect, answerCountWrong int
 	for _, group := range Categories {
@@ -67,24 +74,27 @@ func FetchQuestions() (err error) {
 	return nil
 }
 
-// MsgToVote checks if msg is a valid vote for an answer. It returns the number of the voted answer
-// (indexed 1). If msg isn't valid for a vote MsgToVote returns 0.
+// MsgToVote checks if msg is a valid vote for an answer, i.e. a single digit or letter like "3" or
+// "c" for the third answer. It returns the number of the voted answer (indexed 1). If msg isn't
+// valid for a vote MsgToVote returns 0.
 //
 // If g is nil or the current round g is pointing to is nil all possible votes are valid. With g
 // containing a non-nil round MsgToVote will get the maximum vote and invalidates all votes above,
 // e.g., the current question has 2 answers but msg is a valid vote for answer 3, MsgToVote will
-// return 0, because 3 is not a valid choice at this point.
+// return 0, because 3 is not a valid choice at this point. The same applies to answers removed by a
+// joker.
 func MsgToVote(msg string, g *Game) int {
+	if len(msg) != 1 {
+		return 0
+	}
 	var vote int
-	switch msg {
-	case "1", "a", "A":
-		vote = 1
-	case "2", "b", "B":
-		vote = 2
-	case "3", "c", "C":
-		vote = 3
-	case "4", "d", "D":
-		vote = 4
+	switch c := msg[0]; {
+	case '1' <= c && c < '1'+MaxAnswerCount:
+		vote = int(c-'1') + 1
+	case 'a' <= c && c < 'a'+MaxAnswerCount:
+		vote = int(c-'a') + 1
+	case 'A' <= c && c < 'A'+MaxAnswerCount:
+		vote = int(c-'A') + 1
 	default:
 		return 0
 	}
@@ -98,7 +108,7 @@ func MsgToVote(msg string, g *Game) int {
 	if g.Rounds[g.Current-1] == nil {
 		return vote
 	}
-	if vote > len(g.Rounds[g.Current-1].Answers) {
+	if vote > len(g.Rounds[g.Current-1].Answers) || slices.Contains(g.Rounds[g.Current-1].Removed, vote) {
 		return 0
 	}
 	return vote
@@ -113,6 +123,7 @@ func (c *Connection) JoinTwitchChannel(channel string) {
 
 	TwitchIRC.JoinChannel(channel)
 	joinedChannels[channel] = c
+	c.channel = channel
 }
 
 // LeaveTwitchChannel leaves the twitch channel for the corresponding connection.
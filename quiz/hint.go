@@ -0,0 +1,136 @@
+package quiz
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// HintSettings define when the hints of a question are revealed during a round. At are the points
+// in percent of the round duration, the first hint is revealed at the first point and so on.
+// Penalty is the amount of points taken away for every hint that was revealed before an answer.
+type HintSettings struct {
+	At      []float64 `json:"at"`
+	Penalty int       `json:"penalty"`
+}
+
+// HintEvent is the payload of a HINT event.
+type HintEvent struct {
+	Current int    `json:"current_round"`
+	Number  int    `json:"number"`
+	Hint    string `json:"hint"`
+}
+
+// validate returns an error if the points are not in (0, 100) and ascending, or the penalty is out
+// of range.
+func (h HintSettings) validate() error {
+	for i, at := range h.At {
+		if at <= 0 || at >= 100 {
+			return fmt.Errorf("hint points must be between 0 and 100, got %g", at)
+		}
+		if i > 0 && at <= h.At[i-1] {
+			return fmt.Errorf("hint points must be ascending, got %v", h.At)
+		}
+	}
+	if h.Penalty < 0 || h.Penalty > roundPoints {
+		return fmt.Errorf("hint penalty must be between 0 and %d, got %d", roundPoints, h.Penalty)
+	}
+	return nil
+}
+
+// elapsedTime returns the time the current round was running, without the time it was paused. The
+// caller must hold g.mu.
+func (g *Game) elapsedTime() time.Duration {
+	if g.paused {
+		return g.activeElapsed
+	}
+	return g.activeElapsed + time.Since(g.activeSince)
+}
+
+// scheduleHint starts the timer for the next hint of the current round, if there is one left. The
+// caller must hold g.mu.
+func (g *Game) scheduleHint() {
+	g.stopHintTimer()
+	round := g.round()
+	if round == nil || round.question == nil {
+		return
+	}
+	next := len(g.hintTimes)
+	if next >= len(round.question.Hints) || next >= len(g.Hints.At) {
+		return
+	}
+
+	at := time.Duration(float64(round.Duration) * float64(time.Second) * g.Hints.At[next] / 100)
+	g.hintTimer = time.AfterFunc(max(at-g.elapsedTime(), 0), func() { g.showHint(round, next) })
+}
+
+// stopHintTimer stops the timer of the next hint if it is running. The caller must hold g.mu.
+func (g *Game) stopHintTimer() {
+	if g.hintTimer != nil {
+		g.hintTimer.Stop()
+		g.hintTimer = nil
+	}
+}
+
+// showHint is called by the hint timer to reveal the hint with the given index (indexed 0) of
+// round.
+func (g *Game) showHint(round *Round, index int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.State != STATEQUESTION || g.paused || g.round() != round || len(g.hintTimes) != index {
+		// the round ended, was replaced or the timer was reset while this call was already waiting
+		// for the lock
+		return
+	}
+	if round.question == nil || index >= len(round.question.Hints) {
+		return
+	}
+
+	hint := round.question.Hints[index].Text
+	round.Hints = append(round.Hints, hint)
+	g.hintTimes = append(g.hintTimes, time.Now())
+	g.connection.sendEvent("HINT", HintEvent{
+		Current: g.Current,
+		Number:  index + 1,
+		Hint:    hint,
+	})
+	g.scheduleHint()
+}
+
+// hintsSeen returns the amount of hints that were revealed before a vote at t. delay is the stream
+// delay for votes from chat, who see the hints later. The caller must hold g.mu.
+func (g *Game) hintsSeen(t time.Time, delay time.Duration) (n int) {
+	for _, shown := range g.hintTimes {
+		if !t.Before(shown.Add(delay)) {
+			n++
+		}
+	}
+	return n
+}
+
+// chatHintsSeen returns the median amount of hints the viewers had seen when they voted. The caller
+// must hold g.mu.
+func (g *Game) chatHintsSeen() int {
+	if len(g.chatHints) == 0 {
+		return 0
+	}
+	seen := make([]int, 0, len(g.chatHints))
+	for _, n := range g.chatHints {
+		seen = append(seen, n)
+	}
+	slices.Sort(seen)
+	return seen[(len(seen)-1)/2]
+}
+
+// applyHintPenalty takes away the hint penalty from the points streamer and chat got in the current
+// round. streamerPoints and chatPoints are the points before the round was scored. The caller must
+// hold g.mu.
+func (g *Game) applyHintPenalty(streamerPoints, chatPoints int) {
+	if g.Hints.Penalty == 0 {
+		return
+	}
+	gained := g.Summary.StreamerPoints - streamerPoints
+	g.Summary.StreamerPoints -= min(gained, g.Hints.Penalty*g.streamerHintsSeen)
+	gained = g.Summary.ChatPoints - chatPoints
+	g.Summary.ChatPoints -= min(gained, g.Hints.Penalty*g.chatHintsSeen())
+}
@@ -0,0 +1,153 @@
+package quiz
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"quiz_backend/database"
+)
+
+// QuestionCooldown are the rules for how long a question is not asked again to the same streamer.
+// Questions that were asked before but are out of cooldown are still only used after all questions
+// that were never asked.
+type QuestionCooldown struct {
+	// Games is the amount of most recent games whose questions are not repeated.
+	Games int `json:"games"`
+	// Days is the amount of days in which asked questions are not repeated.
+	Days int `json:"days"`
+}
+
+// validate returns an error if one of the rules is negative.
+func (c QuestionCooldown) validate() error {
+	if c.Games < 0 || c.Days < 0 {
+		return fmt.Errorf("question_cooldown must not be negative, got %+v", c)
+	}
+	return nil
+}
+
+// questionHistory are the questions a streamer was asked before. seen are all questions ever
+// asked, blocked the ones that are in cooldown. Both are keyed by [Question.ID].
+type questionHistory struct {
+	seen    map[string]bool
+	blocked map[string]bool
+}
+
+// ID returns the identity of q, which stays the same as long as the question does not change. It
+// covers the category, type, text and answers of q, so questions with the same wording in
+// different categories or with different answers are told apart. The order of the answers in the
+// sheet does not matter, except for the items of ordering questions.
+func (q Question) ID() string {
+	contents := func(list []DisplayableContent, sorted bool) string {
+		texts := make([]string, 0, len(list))
+		for _, c := range list {
+			texts = append(texts, c.Text)
+		}
+		if sorted {
+			slices.Sort(texts)
+		}
+		return strings.Join(texts, "\x1f")
+	}
+	pairs := make([]string, 0, len(q.Pairs))
+	for _, p := range q.Pairs {
+		pairs = append(pairs, p.Left.Text+"\x1e"+p.Right.Text)
+	}
+	slices.Sort(pairs)
+
+	h := sha256.New()
+	for _, field := range []string{
+		q.categoryID,
+		q.Type.String(),
+		q.Question.Text,
+		contents(q.Correct, true),
+		contents(q.Wrong, true),
+		contents(q.Items, false),
+		strings.Join(pairs, "\x1f"),
+	} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// loadQuestionHistory loads the question history of the user with the given ID from the database.
+func loadQuestionHistory(userID string, cooldown QuestionCooldown) (questionHistory, error) {
+	history := questionHistory{
+		seen:    make(map[string]bool),
+		blocked: make(map[string]bool),
+	}
+	if userID == "" {
+		return history, nil
+	}
+	entries, err := database.GetQuestionHistory(userID)
+	if err != nil {
+		return history, fmt.Errorf("load question history: %v", err)
+	}
+
+	since := time.Now().AddDate(0, 0, -cooldown.Days)
+	// games are the IDs of the most recent games, the entries are sorted from new to old
+	games := make(map[string]bool)
+	for _, entry := range entries {
+		history.seen[entry.QuestionID] = true
+		if !games[entry.GameID] && len(games) < cooldown.Games {
+			games[entry.GameID] = true
+		}
+		if games[entry.GameID] || cooldown.Days > 0 && entry.AskedAt.After(since) {
+			history.blocked[entry.QuestionID] = true
+		}
+	}
+	return history, nil
+}
+
+// unseen returns the amount of questions in c that were never asked.
+func (h questionHistory) unseen(c Category) (n int) {
+	for _, q := range c.Pool {
+		if q != nil && !h.seen[q.ID()] {
+			n++
+		}
+	}
+	return n
+}
+
+// recordQuestion saves in the background that the question of the current round was asked. The
+// caller must hold g.mu.
+func (g *Game) recordQuestion() {
+	round := g.round()
+	if round == nil || round.question == nil || g.connection == nil || g.connection.userID == "" {
+		return
+	}
+	userID, gameID, questionID := g.connection.userID, g.id, round.question.ID()
+	go func() {
+		err := database.AddQuestionHistory(userID, gameID, questionID)
+		if err != nil {
+			log.Printf("Error saving question history of user %s: %v", userID, err)
+		}
+	}()
+}
+
+// CategoryDefinitions returns the definitions of all categories like [categoryGroups.GetDefinition],
+// with the amount of questions in each category that c was never asked.
+func (c *Connection) CategoryDefinitions() (map[int]CategoryGroupDefinition, error) {
+	history, err := loadQuestionHistory(c.userID, QuestionCooldown{})
+	if err != nil {
+		return nil, err
+	}
+	definitions := make(map[int]CategoryGroupDefinition, len(Categories))
+	for color, group := range Categories {
+		definition := group.GetDefinition()
+		for i, category := range group.Categories {
+			unseen := history.unseen(category)
+			definition.Categories[i].Unseen = &unseen
+		}
+		definitions[color] = definition
+	}
+	return definitions, nil
+}
+
+// ResetQuestionHistory deletes the question history of c, so all questions count as unseen again.
+func (c *Connection) ResetQuestionHistory() error {
+	return database.ResetQuestionHistory(c.userID)
+}
@@ -0,0 +1,202 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// defaultJokerCount is the amount of each joker available in a game, if not set otherwise.
+const defaultJokerCount = 1
+
+// ErrNoJokerLeft is returned when the streamer tries to use a joker that was already used up.
+var ErrNoJokerLeft = errors.New("no joker left")
+
+// Jokers is the stock of lifelines the streamer can use in a game.
+type Jokers struct {
+	// FiftyFifty removes two wrong answers.
+	FiftyFifty int `json:"fifty_fifty"`
+	// AskChat shows the current distribution of chat votes to the streamer.
+	AskChat int `json:"ask_chat"`
+	// Swap replaces the question by another one from the same category.
+	Swap int `json:"swap"`
+}
+
+// JokerResult is the payload sent when a joker is used. Depending on the joker it contains the
+// updated round or the chat votes.
+type JokerResult struct {
+	Joker            string       `json:"joker"`
+	Jokers           Jokers       `json:"jokers"`
+	Round            *RoundStatus `json:"round,omitempty"`
+	ChatVoteCount    []int        `json:"chat_vote_count,omitempty"`
+	ChatVoteWeighted []float64    `json:"chat_vote_weighted,omitempty"`
+	ChatVoters       int          `json:"chat_voters,omitempty"`
+}
+
+// checkJoker checks if the streamer can use a joker with the given stock right now. The caller must
+// hold g.mu.
+func (g *Game) checkJoker(name string, stock int) error {
+	if err := g.checkState("use a joker", STATEQUESTION); err != nil {
+		return err
+	}
+	if g.paused {
+		return fmt.Errorf("%w: cannot use a joker while the round is paused", ErrInvalidState)
+	}
+	if g.streamerHasVoted() {
+		return fmt.Errorf("%w: cannot use a joker after voting", ErrInvalidState)
+	}
+	if stock <= 0 {
+		return fmt.Errorf("%w: %s", ErrNoJokerLeft, name)
+	}
+	return nil
+}
+
+// streamerHasVoted reports whether the streamer already voted in the current round. The caller must
+// hold g.mu.
+func (g *Game) streamerHasVoted() bool {
+	return g.StreamerVote != 0 || g.streamerGuess != nil || g.streamerText != nil ||
+		g.streamerSelection != 0 || g.streamerOrder != nil
+}
+
+// UseFiftyFifty removes two wrong answers from the current multiple choice round. The answers keep
+// their numbers, the removed ones are listed in [Round.Removed] and can't be voted for anymore.
+func (g *Game) UseFiftyFifty() (JokerResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkJoker("fifty_fifty", g.Jokers.FiftyFifty); err != nil {
+		return JokerResult{}, err
+	}
+	round := g.round()
+	if round.Type != QUESTIONCHOICE {
+		return JokerResult{}, fmt.Errorf("%w: fifty_fifty can only be used in multiple choice rounds", ErrInvalidState)
+	}
+	if len(round.Removed) > 0 {
+		return JokerResult{}, fmt.Errorf("%w: fifty_fifty was already used in this round", ErrInvalidState)
+	}
+
+	var wrong []int
+	for i := range round.Answers {
+		if i+1 != round.Correct {
+			wrong = append(wrong, i+1)
+		}
+	}
+	if len(wrong) < 2 {
+		return JokerResult{}, fmt.Errorf("%w: too few wrong answers to remove", ErrInvalidState)
+	}
+	g.rng.Shuffle(len(wrong), func(i, j int) {
+		wrong[i], wrong[j] = wrong[j], wrong[i]
+	})
+	round.Removed = wrong[:2]
+	slices.Sort(round.Removed)
+	g.Jokers.FiftyFifty--
+
+	status, _ := g.currentRound()
+	result := JokerResult{
+		Joker:  "fifty_fifty",
+		Jokers: g.Jokers,
+		Round:  &status,
+	}
+	g.connection.sendEvent("JOKER_USED", result)
+	return result, nil
+}
+
+// UseAskChat returns the current distribution of chat votes, so the streamer can see it before
+// voting.
+func (g *Game) UseAskChat() (JokerResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkJoker("ask_chat", g.Jokers.AskChat); err != nil {
+		return JokerResult{}, err
+	}
+	if t := g.round().Type; t != QUESTIONCHOICE && t != QUESTIONMULTI {
+		return JokerResult{}, fmt.Errorf("%w: ask_chat can only be used in rounds with answers to choose from", ErrInvalidState)
+	}
+	g.Jokers.AskChat--
+
+	result := JokerResult{
+		Joker:            "ask_chat",
+		Jokers:           g.Jokers,
+		ChatVoteCount:    slices.Clone(g.ChatVoteCount),
+		ChatVoteWeighted: slices.Clone(g.ChatVoteWeighted),
+		ChatVoters:       len(g.chatVotes),
+	}
+	g.connection.sendEvent("JOKER_USED", result)
+	return result, nil
+}
+
+// UseSwap replaces the current round with a question from the same category that was not used in
+// this game yet. All votes are reset and the round timer starts again.
+func (g *Game) UseSwap() (JokerResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkJoker("swap", g.Jokers.Swap); err != nil {
+		return JokerResult{}, err
+	}
+	if g.match != nil {
+		return JokerResult{}, fmt.Errorf("%w: swap cannot be used in a match", ErrInvalidState)
+	}
+	if err := g.replaceRound(); err != nil {
+		return JokerResult{}, err
+	}
+	g.Jokers.Swap--
+
+	g.startRound()
+	status, _ := g.currentRound()
+	result := JokerResult{
+		Joker:  "swap",
+		Jokers: g.Jokers,
+		Round:  &status,
+	}
+	g.connection.sendEvent("JOKER_USED", result)
+	return result, nil
+}
+
+// replaceRound replaces the current round with a random question from the same category that was
+// not used in this game yet. Like [Category.GetRounds] it skips blocked questions and prefers
+// questions the streamer was never asked. The caller must hold g.mu and start the new round.
+func (g *Game) replaceRound() error {
+	round := g.round()
+
+	category := Categories.GetCategoryByID(round.Category.ID)
+	var unseen, seen []*Question
+	for _, q := range category.Pool {
+		switch {
+		case q == nil || g.isUsed(q) || g.history.blocked[q.ID()]:
+		case g.history.seen[q.ID()]:
+			seen = append(seen, q)
+		default:
+			unseen = append(unseen, q)
+		}
+	}
+	unused := unseen
+	if len(unused) == 0 {
+		unused = seen
+	}
+	if len(unused) == 0 {
+		return fmt.Errorf("%w: no unused question left in category '%s'", ErrInvalidState, round.Category.ID)
+	}
+
+	q := unused[g.rng.Intn(len(unused))]
+	newRound := q.ToRound(g.rng, g.AnswerCount)
+	newRound.question = q
+	newRound.Current = round.Current
+	newRound.Max = round.Max
+	newRound.Group = round.Group
+	newRound.Category = round.Category
+	newRound.Duration = int(g.roundDuration(&newRound).Seconds())
+	g.Rounds[g.Current-1] = &newRound
+	return nil
+}
+
+// isUsed reports whether q is already used by a round of g. Questions are compared by
+// [Question.ID], because the categories are replaced when the questions are fetched again. The
+// caller must hold g.mu.
+func (g *Game) isUsed(q *Question) bool {
+	id := q.ID()
+	for _, r := range g.Rounds {
+		if r.question != nil && r.question.ID() == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// matchCodeLength is the length of an invite code. matchCodeAlphabet leaves out characters that are
+// easily confused when read out on stream.
+const (
+	matchCodeLength   = 6
+	matchCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// ErrMatchNotFound is returned when there is no match with a given invite code.
+var ErrMatchNotFound = errors.New("match not found")
+
+var (
+	// matches is a map of invite codes to all currently open matches
+	matches   = make(map[string]*Match)
+	matchesMu sync.Mutex
+)
+
+// Match is a quiz played by several streamers at the same time. Every participant has their own
+// [Game] with the same rounds, so streamer and chat of each channel vote and score on their own. The
+// host starts the rounds for everyone and all participants get a combined scoreboard.
+type Match struct {
+	mu   sync.Mutex
+	Code string
+	host *Game
+	// current is the round the host is in
+	current int
+	// players are all participants of the match, starting with the host
+	players []*matchPlayer
+}
+
+// matchPlayer is a single channel taking part in a match.
+type matchPlayer struct {
+	connection *Connection
+	game       *Game
+	score      GameSummary
+}
+
+// MatchScore is the score of a single channel in a match.
+type MatchScore struct {
+	Channel string `json:"channel"`
+	Host    bool   `json:"host"`
+	GameSummary
+}
+
+// MatchScoreboard is the combined scoreboard of all channels in a match.
+type MatchScoreboard struct {
+	Code    string       `json:"code"`
+	Current int          `json:"current_round"`
+	Max     int          `json:"max_round"`
+	Scores  []MatchScore `json:"scores"`
+}
+
+// newMatchCode returns a random invite code that is not used by another match. The caller must hold
+// matchesMu.
+func newMatchCode() string {
+	code := make([]byte, matchCodeLength)
+	for {
+		for i := range code {
+			code[i] = matchCodeAlphabet[rand.Intn(len(matchCodeAlphabet))]
+		}
+		if _, used := matches[string(code)]; !used {
+			return string(code)
+		}
+	}
+}
+
+// CreateMatch opens a match for the game of c, so other streamers can join it with the returned
+// invite code. The game has to be in the lobby.
+func (c *Connection) CreateMatch() (*Match, error) {
+	if c.Game == nil {
+		return nil, fmt.Errorf("%w: no game to create a match for", ErrInvalidState)
+	}
+	g := c.Game
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkState("create a match", STATELOBBY); err != nil {
+		return nil, err
+	}
+	if g.match != nil {
+		return nil, fmt.Errorf("%w: the game already is part of match %s", ErrInvalidState, g.match.Code)
+	}
+
+	matchesMu.Lock()
+	defer matchesMu.Unlock()
+	m := &Match{
+		Code: newMatchCode(),
+		host: g,
+		players: []*matchPlayer{{
+			connection: c,
+			game:       g,
+		}},
+	}
+	matches[m.Code] = m
+	g.match = m
+	return m, nil
+}
+
+// JoinMatch lets c take part in the match with the given invite code. Any current game of c is
+// replaced by a copy of the game of the host. Joining is only possible until the host started the
+// first round.
+func (c *Connection) JoinMatch(code string) (*Match, error) {
+	matchesMu.Lock()
+	m, ok := matches[code]
+	matchesMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrMatchNotFound, code)
+	}
+	if c.Game != nil && c.Game == m.host {
+		return nil, fmt.Errorf("%w: cannot join your own match", ErrInvalidState)
+	}
+
+	// the guest is added while holding the lock of the host, so the host can't start the first round
+	// before the guest is a participant
+	m.host.mu.Lock()
+	if err := m.host.checkState("join the match", STATELOBBY); err != nil {
+		m.host.mu.Unlock()
+		return nil, err
+	}
+	g := m.host.cloneForMatch(c)
+	m.mu.Lock()
+	m.players = append(m.players, &matchPlayer{
+		connection: c,
+		game:       g,
+	})
+	m.mu.Unlock()
+	m.host.mu.Unlock()
+
+	c.LeaveMatch()
+	if c.Game != nil {
+		c.Game.Stop()
+	}
+	c.Game = g
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.broadcast("MATCH_JOINED")
+	return m, nil
+}
+
+// LeaveMatch removes c from its match. If c is the host, the match is closed for all participants,
+// who keep playing their games on their own. LeaveMatch does nothing if c is in no match.
+func (c *Connection) LeaveMatch() {
+	if c.Game == nil {
+		return
+	}
+	g := c.Game
+	g.mu.Lock()
+	m := g.match
+	g.match = nil
+	g.mu.Unlock()
+	if m == nil {
+		return
+	}
+
+	if m.host != g {
+		m.mu.Lock()
+		// only the old game is removed, c may already take part again with a new one
+		m.players = slices.DeleteFunc(m.players, func(p *matchPlayer) bool {
+			return p.game == g
+		})
+		m.broadcast("MATCH_LEFT")
+		m.mu.Unlock()
+		return
+	}
+	m.close()
+}
+
+// GetMatch returns the scoreboard of the match c takes part in and reports whether c is in a match.
+func (c *Connection) GetMatch() (MatchScoreboard, bool) {
+	if c.Game == nil {
+		return MatchScoreboard{}, false
+	}
+	c.Game.mu.Lock()
+	m := c.Game.match
+	c.Game.mu.Unlock()
+	if m == nil {
+		return MatchScoreboard{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.scoreboard(), true
+}
+
+// close removes m from the open matches and detaches all participants.
+func (m *Match) close() {
+	matchesMu.Lock()
+	delete(matches, m.Code)
+	matchesMu.Unlock()
+
+	m.mu.Lock()
+	players := m.players
+	m.broadcast("MATCH_CLOSED")
+	m.players = nil
+	m.mu.Unlock()
+
+	for _, p := range players {
+		p.game.mu.Lock()
+		if p.game.match == m {
+			p.game.match = nil
+		}
+		p.game.mu.Unlock()
+	}
+}
+
+// cloneForMatch returns a new game for c with the same settings and rounds as g. Rounds are started
+// by the host, so auto advance is disabled and the swap joker can't be used. The caller must hold
+// g.mu.
+func (g *Game) cloneForMatch(c *Connection) *Game {
+	rounds := make([]*Round, len(g.Rounds))
+	for i, r := range g.Rounds {
+		round := *r
+		rounds[i] = &round
+	}
+	jokers := g.Jokers
+	jokers.Swap = 0
+
+	clone := &Game{
+		id:                uuid.NewString(),
+		connection:        c,
+		Rounds:            rounds,
+		RoundDuration:     g.RoundDuration,
+		CategoryDurations: g.CategoryDurations,
+		TextTolerance:     g.TextTolerance,
+		AnswerCount:       g.AnswerCount,
+		Jokers:            jokers,
+		VoteChangePolicy:  g.VoteChangePolicy,
+		MaxVoteChanges:    g.MaxVoteChanges,
+		VoteWeights:       g.VoteWeights,
+		Consensus:         g.Consensus,
+		Hints:             g.Hints,
+		Summary:           &GameSummary{Seed: g.Summary.Seed},
+		seed:              g.seed,
+		rng:               rand.New(rand.NewSource(g.seed)),
+		chatVotes:         make(map[string]viewerVote),
+		teamMembers:       make(map[string]string),
+		match:             g.match,
+	}
+	if g.TeamMode != nil {
+		teamMode := *g.TeamMode
+		clone.TeamMode = &teamMode
+		clone.Summary.Teams = make(map[string]*TeamSummary, len(teamMode.Teams))
+		for _, team := range teamMode.Teams {
+			clone.Summary.Teams[team] = &TeamSummary{}
+		}
+	}
+	return clone
+}
+
+// isGuest reports whether g takes part in a match it is not the host of. Rounds of guests are only
+// started by the host. The caller must hold g.mu.
+func (g *Game) isGuest() bool {
+	return g.match != nil && g.match.host != g
+}
+
+// isHost reports whether g is the host of a match. The caller must hold g.mu.
+func (g *Game) isHost() bool {
+	return g.match != nil && g.match.host == g
+}
+
+// follow brings the games of all guests to the same round as the host. It must be called without
+// holding the lock of any game.
+func (m *Match) follow() {
+	m.mu.Lock()
+	players := slices.Clone(m.players)
+	m.mu.Unlock()
+
+	m.host.mu.Lock()
+	current := m.host.Current
+	finished := m.host.State == STATEFINISHED
+	m.host.mu.Unlock()
+	m.mu.Lock()
+	m.current = current
+	m.mu.Unlock()
+
+	for _, p := range players {
+		if p.game == m.host {
+			continue
+		}
+		p.game.mu.Lock()
+		if p.game.match == m {
+			p.game.followRound(current, finished)
+		}
+		p.game.mu.Unlock()
+	}
+	if finished {
+		m.close()
+	}
+}
+
+// followRound brings a guest to round current of the host. The round the guest is in is closed and
+// skipped rounds are started and closed right away. If the host finished, the game of the guest is
+// finished too. The caller must hold g.mu.
+func (g *Game) followRound(current int, finished bool) {
+	for g.State != STATEFINISHED && (g.Current < current || finished) {
+		if g.State == STATEQUESTION {
+			g.closeVoting()
+		}
+		if g.State == STATEVOTINGCLOSED {
+			g.reveal()
+		}
+		if err := g.nextRound(); err != nil {
+			log.Printf("Error following the host of match %s: %v", g.match.Code, err)
+			return
+		}
+	}
+}
+
+// report updates the score of g in m and sends the new scoreboard to all participants. The caller
+// must hold g.mu.
+func (m *Match) report(g *Game) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.players {
+		if p.game != g {
+			continue
+		}
+		p.score = g.Summary.clone()
+	}
+	m.broadcast("MATCH_SCOREBOARD")
+}
+
+// scoreboard returns the current scores of all participants. The caller must hold m.mu.
+func (m *Match) scoreboard() MatchScoreboard {
+	sb := MatchScoreboard{
+		Code:    m.Code,
+		Current: m.current,
+		// the rounds are fixed once the match is created, so this is safe without the lock of the host
+		Max:    len(m.host.Rounds),
+		Scores: make([]MatchScore, 0, len(m.players)),
+	}
+	for _, p := range m.players {
+		sb.Scores = append(sb.Scores, MatchScore{
+			Channel:     p.connection.channel,
+			Host:        p.game == m.host,
+			GameSummary: p.score,
+		})
+	}
+	return sb
+}
+
+// broadcast sends the scoreboard as event of the given type to all participants. The caller must
+// hold m.mu.
+func (m *Match) broadcast(eventType string) {
+	sb := m.scoreboard()
+	for _, p := range m.players {
+		p.connection.sendEvent(eventType, sb)
+	}
+}
@@ -0,0 +1,176 @@
+package quiz
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"strings"
+)
+
+// MultiSummary is the result of a "select all that apply" round. Selections are lists of answer
+// numbers (indexed 1).
+type MultiSummary struct {
+	StreamerSelection []int `json:"streamer_selection"`
+	ChatSelection     []int `json:"chat_selection"`
+	ChatVoters        int   `json:"chat_voters"`
+}
+
+// MsgToSelection checks if msg is a valid vote for a "select all that apply" round, like "AC",
+// "a c" or "1,3". Answers are either separated by spaces, commas, "+" or "&", or written together
+// as uppercase letters. Lowercase letters or digits written together are no selection, so that
+// regular chat messages like "bad" or "cab" are not counted. It returns the selected answers as
+// bitmask, where bit 0 is the first answer. If msg isn't a valid selection MsgToSelection returns
+// 0. Selecting an answer twice is invalid too.
+//
+// Same as with [MsgToVote] a selection is only valid if all selected answers exist in the current
+// round of g.
+func MsgToSelection(msg string, g *Game) (selection uint) {
+	answers := strings.FieldsFunc(msg, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '+' || r == '&'
+	})
+	if len(answers) == 1 && len(answers[0]) > 1 {
+		for _, r := range answers[0] {
+			if r < 'A' || 'Z' < r {
+				return 0
+			}
+		}
+		answers = strings.Split(answers[0], "")
+	}
+	if len(answers) == 0 {
+		return 0
+	}
+	for _, answer := range answers {
+		vote := MsgToVote(answer, g)
+		if vote == 0 || selection&(1<<(vote-1)) != 0 {
+			return 0
+		}
+		selection |= 1 << (vote - 1)
+	}
+	return selection
+}
+
+// selectionToList converts a selection bitmask to a sorted list of answer numbers (indexed 1).
+func selectionToList(selection uint) []int {
+	list := make([]int, 0, bits.OnesCount(selection))
+	for i := 0; selection>>i != 0; i++ {
+		if selection&(1<<i) != 0 {
+			list = append(list, i+1)
+		}
+	}
+	return list
+}
+
+// listToSelection is the inverse of [selectionToList].
+func listToSelection(list []int) (selection uint) {
+	for _, i := range list {
+		selection |= 1 << (i - 1)
+	}
+	return selection
+}
+
+// selectionPoints returns the points for selection when correct is the correct selection. Every
+// correct answer selected gives a share of the round points and every wrong answer selected takes
+// one away. It reports whether the selection is exactly right as well.
+func selectionPoints(selection, correct uint) (points int, exact bool) {
+	hits := bits.OnesCount(selection & correct)
+	misses := bits.OnesCount(selection &^ correct)
+	share := float64(hits-misses) / float64(bits.OnesCount(correct))
+	if share <= 0 {
+		return 0, false
+	}
+	return int(math.Round(float64(roundPoints) * share)), selection == correct
+}
+
+// scoreMulti scores the current "select all that apply" round. Chat selects every answer that was
+// picked by more than half of the voters, or more than half of the vote weight with vote weights
+// set. Chat does not select anything with less voters than [Consensus.MinVoters]. The caller must
+// hold g.mu.
+func (g *Game) scoreMulti(round *Round) {
+	correct := listToSelection(round.CorrectAll)
+	sum := &MultiSummary{
+		StreamerSelection: selectionToList(g.streamerSelection),
+		ChatVoters:        len(g.chatVotes),
+	}
+	g.multiSummary = sum
+
+	if g.streamerSelection != 0 {
+		points, exact := selectionPoints(g.streamerSelection, correct)
+		g.Summary.StreamerPoints += points
+		if exact {
+			g.Summary.StreamerWon++
+		}
+	}
+
+	if len(g.chatVotes) == 0 {
+		g.chatDecision = DECISIONNOVOTES
+		return
+	}
+	if len(g.chatVotes) < g.Consensus.MinVoters {
+		g.chatDecision = DECISIONMINVOTERS
+		return
+	}
+	g.chatDecision = DECISIONPLURALITY
+
+	var chatSelection uint
+	total := g.chatTallyTotal()
+	for i, votes := range g.chatTally() {
+		if votes*2 > total {
+			chatSelection |= 1 << i
+		}
+	}
+	sum.ChatSelection = selectionToList(chatSelection)
+	if chatSelection != 0 {
+		points, exact := selectionPoints(chatSelection, correct)
+		g.Summary.ChatPoints += points
+		if exact {
+			g.Summary.ChatWon++
+		}
+	}
+}
+
+// toMultiRound converts q to a "select all that apply" round. All correct answers are shown, filled
+// up with wrong answers to a total of n.
+func (q Question) toMultiRound(rng *rand.Rand, n int) Round {
+	correct := slices.Clone(q.Correct)
+	wrong := slices.Clone(q.Wrong)
+	rng.Shuffle(len(wrong), func(i, j int) {
+		wrong[i], wrong[j] = wrong[j], wrong[i]
+	})
+	if len(correct) > n-1 {
+		// keep at least one wrong answer
+		rng.Shuffle(len(correct), func(i, j int) {
+			correct[i], correct[j] = correct[j], correct[i]
+		})
+		correct = correct[:n-1]
+	}
+	wrong = wrong[:min(len(wrong), n-len(correct))]
+
+	answers := make([]string, 0, len(correct)+len(wrong))
+	isCorrect := make([]bool, 0, cap(answers))
+	for _, a := range correct {
+		answers = append(answers, a.Text)
+		isCorrect = append(isCorrect, true)
+	}
+	for _, a := range wrong {
+		answers = append(answers, a.Text)
+		isCorrect = append(isCorrect, false)
+	}
+	rng.Shuffle(len(answers), func(i, j int) {
+		answers[i], answers[j] = answers[j], answers[i]
+		isCorrect[i], isCorrect[j] = isCorrect[j], isCorrect[i]
+	})
+
+	var correctAll []int
+	for i, c := range isCorrect {
+		if c {
+			correctAll = append(correctAll, i+1)
+		}
+	}
+	return Round{
+		Type:       QUESTIONMULTI,
+		Question:   q.Question.Text,
+		Answers:    answers,
+		CorrectAll: correctAll,
+	}
+}
@@ -0,0 +1,222 @@
+package quiz
+
+import (
+	"cmp"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+)
+
+// MatchPair is a pair of a matching question, where Left has to be matched to Right.
+type MatchPair struct {
+	Left  DisplayableContent `json:"left"`
+	Right DisplayableContent `json:"right"`
+}
+
+// OrderSummary is the result of an ordering or matching round. Orders are lists of answer numbers
+// (indexed 1). In ordering rounds they are the answers from first to last, in matching rounds the
+// answer matched to each item of [Round.Left].
+type OrderSummary struct {
+	StreamerOrder []int `json:"streamer_order"`
+	ChatOrder     []int `json:"chat_order"`
+	ChatVoters    int   `json:"chat_voters"`
+}
+
+// MsgToPermutation checks if msg is a valid vote for an ordering or matching round, like "BDAC",
+// "2 4 1 3" or "b > d > a > c". Same as with [MsgToSelection], answers are either separated by
+// spaces, commas, "-" or ">", or written together as uppercase letters, so that regular chat
+// messages like "cab" are not counted. Every answer of the current round of g has to be used
+// exactly once. It returns the answer numbers (indexed 1) in the order of msg, or nil if msg isn't
+// a valid vote.
+func MsgToPermutation(msg string, g *Game) []int {
+	round := g.round()
+	if round == nil {
+		return nil
+	}
+	answers := strings.FieldsFunc(msg, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '-' || r == '>'
+	})
+	if len(answers) == 1 && len(answers[0]) > 1 {
+		for _, r := range answers[0] {
+			if r < 'A' || 'Z' < r {
+				return nil
+			}
+		}
+		answers = strings.Split(answers[0], "")
+	}
+	if len(answers) != len(round.Answers) {
+		return nil
+	}
+
+	permutation := make([]int, 0, len(round.Answers))
+	used := make([]bool, len(round.Answers))
+	for _, answer := range answers {
+		vote := MsgToVote(answer, g)
+		if vote == 0 || used[vote-1] {
+			return nil
+		}
+		used[vote-1] = true
+		permutation = append(permutation, vote)
+	}
+	return permutation
+}
+
+// kendallDistance returns the number of pairs that are in a different order in a than in b,
+// normalized to [0, 1]. a and b must be permutations of the same answers.
+func kendallDistance(a, b []int) float64 {
+	position := make(map[int]int, len(b))
+	for i, v := range b {
+		position[v] = i
+	}
+	var discordant, pairs int
+	for i := range a {
+		for j := i + 1; j < len(a); j++ {
+			pairs++
+			if position[a[i]] > position[a[j]] {
+				discordant++
+			}
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return float64(discordant) / float64(pairs)
+}
+
+// hammingDistance returns the share of positions where a and b differ.
+func hammingDistance(a, b []int) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+	var diff int
+	for i := range b {
+		if i >= len(a) || a[i] != b[i] {
+			diff++
+		}
+	}
+	return float64(diff) / float64(len(b))
+}
+
+// orderPoints returns the points for order in round and reports whether it is completely correct.
+// Ordering rounds are scored by the amount of swapped pairs, matching rounds by the amount of wrong
+// matches.
+func orderPoints(order []int, round *Round) (points int, exact bool) {
+	var distance float64
+	if round.Type == QUESTIONMATCH {
+		distance = hammingDistance(order, round.CorrectOrder)
+	} else {
+		distance = kendallDistance(order, round.CorrectOrder)
+	}
+	return int(math.Round(float64(roundPoints) * (1 - distance))), distance == 0
+}
+
+// chatOrder combines the votes of all viewers to the order of chat. In ordering rounds the answers
+// are sorted by their average position, in matching rounds each item gets the answer most viewers
+// matched to it.
+func chatOrder(votes map[string][]int, round *Round) []int {
+	n := len(round.Answers)
+	if round.Type == QUESTIONMATCH {
+		order := make([]int, n)
+		for i := range order {
+			counts := make([]int, n+1)
+			for _, vote := range votes {
+				counts[vote[i]]++
+			}
+			order[i] = slices.Index(counts, slices.Max(counts))
+		}
+		return order
+	}
+
+	positionSum := make([]int, n+1)
+	for _, vote := range votes {
+		for position, answer := range vote {
+			positionSum[answer] += position
+		}
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i + 1
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(positionSum[a], positionSum[b])
+	})
+	return order
+}
+
+// scoreOrder scores the current ordering or matching round. The caller must hold g.mu.
+func (g *Game) scoreOrder(round *Round) {
+	sum := &OrderSummary{
+		StreamerOrder: g.streamerOrder,
+		ChatVoters:    len(g.chatOrders),
+	}
+	g.orderSummary = sum
+
+	if g.streamerOrder != nil {
+		points, exact := orderPoints(g.streamerOrder, round)
+		g.Summary.StreamerPoints += points
+		if exact {
+			g.Summary.StreamerWon++
+		}
+	}
+
+	if len(g.chatOrders) == 0 {
+		return
+	}
+	sum.ChatOrder = chatOrder(g.chatOrders, round)
+	points, exact := orderPoints(sum.ChatOrder, round)
+	g.Summary.ChatPoints += points
+	if exact {
+		g.Summary.ChatWon++
+	}
+}
+
+// toOrderRound converts q to an ordering round. If q has more than n items, a random selection is
+// used, while keeping their relative order.
+func (q Question) toOrderRound(rng *rand.Rand, n int) Round {
+	indices := rng.Perm(len(q.Items))[:min(len(q.Items), n)]
+	slices.Sort(indices)
+
+	answers := make([]string, len(indices))
+	for i, index := range indices {
+		answers[i] = q.Items[index].Text
+	}
+	// positions[i] is the position of the i-th item in the shuffled answers
+	positions := rng.Perm(len(answers))
+	shuffled := make([]string, len(answers))
+	correctOrder := make([]int, len(answers))
+	for i, position := range positions {
+		shuffled[position] = answers[i]
+		correctOrder[i] = position + 1
+	}
+	return Round{
+		Type:         QUESTIONORDER,
+		Question:     q.Question.Text,
+		Answers:      shuffled,
+		CorrectOrder: correctOrder,
+	}
+}
+
+// toMatchRound converts q to a matching round. If q has more than n pairs, a random selection is
+// used.
+func (q Question) toMatchRound(rng *rand.Rand, n int) Round {
+	indices := rng.Perm(len(q.Pairs))[:min(len(q.Pairs), n)]
+
+	left := make([]string, len(indices))
+	answers := make([]string, len(indices))
+	// positions[i] is the position of the right side of pair i in the answers
+	positions := rng.Perm(len(indices))
+	correctOrder := make([]int, len(indices))
+	for i, index := range indices {
+		left[i] = q.Pairs[index].Left.Text
+		answers[positions[i]] = q.Pairs[index].Right.Text
+		correctOrder[i] = positions[i] + 1
+	}
+	return Round{
+		Type:         QUESTIONMATCH,
+		Question:     q.Question.Text,
+		Left:         left,
+		Answers:      answers,
+		CorrectOrder: correctOrder,
+	}
+}
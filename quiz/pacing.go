@@ -0,0 +1,192 @@
+package quiz
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"quiz_backend/database"
+)
+
+// MinDifficulty and MaxDifficulty are the limits of the difficulty of a question.
+const (
+	MinDifficulty = 1
+	MaxDifficulty = 5
+)
+
+// defaultDifficulty is the difficulty of questions without a difficulty in the sheet and too few
+// measured results.
+const defaultDifficulty = 3
+
+// minMeasuredRounds is the amount of rounds a question has to be asked in before its correct rate is
+// used as difficulty.
+const minMeasuredRounds = 5
+
+// RoundOrder is the strategy used to order the rounds of a game.
+type RoundOrder uint8
+
+const (
+	// ROUNDORDERRANDOM shuffles all rounds. This is the default.
+	ROUNDORDERRANDOM RoundOrder = iota
+	// ROUNDORDERDIFFICULTY starts with the easiest rounds and ends with the hardest ones.
+	ROUNDORDERDIFFICULTY
+	// ROUNDORDERBLOCKS plays all rounds of a category in a row, the categories in random order.
+	ROUNDORDERBLOCKS
+	// ROUNDORDERALTERNATE never plays the same category twice in a row, as long as there are rounds
+	// of other categories left.
+	ROUNDORDERALTERNATE
+)
+
+func (o RoundOrder) String() string {
+	switch o {
+	case ROUNDORDERRANDOM:
+		return "random"
+	case ROUNDORDERDIFFICULTY:
+		return "difficulty"
+	case ROUNDORDERBLOCKS:
+		return "blocks"
+	case ROUNDORDERALTERNATE:
+		return "alternate"
+	default:
+		return fmt.Sprintf("RoundOrder(%d)", o)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The order is encoded as its string representation.
+func (o RoundOrder) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. An empty string results in [ROUNDORDERRANDOM].
+func (o *RoundOrder) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	switch s {
+	case "", "random":
+		*o = ROUNDORDERRANDOM
+	case "difficulty":
+		*o = ROUNDORDERDIFFICULTY
+	case "blocks":
+		*o = ROUNDORDERBLOCKS
+	case "alternate":
+		*o = ROUNDORDERALTERNATE
+	default:
+		return fmt.Errorf("unknown round order '%s'", s)
+	}
+	return nil
+}
+
+// arrange orders rounds in place by the strategy o. Rounds that are equal for the strategy are in
+// random order.
+func (o RoundOrder) arrange(rng *rand.Rand, rounds []*Round) {
+	rng.Shuffle(len(rounds), func(i, j int) {
+		rounds[i], rounds[j] = rounds[j], rounds[i]
+	})
+
+	switch o {
+	case ROUNDORDERDIFFICULTY:
+		slices.SortStableFunc(rounds, func(a, b *Round) int {
+			return cmp.Compare(a.Difficulty, b.Difficulty)
+		})
+	case ROUNDORDERBLOCKS:
+		// the blocks are in the order the categories first appear in the shuffled rounds
+		blocks := make(map[string]int)
+		for _, r := range rounds {
+			if _, ok := blocks[r.Category.ID]; !ok {
+				blocks[r.Category.ID] = len(blocks)
+			}
+		}
+		slices.SortStableFunc(rounds, func(a, b *Round) int {
+			return cmp.Compare(blocks[a.Category.ID], blocks[b.Category.ID])
+		})
+	case ROUNDORDERALTERNATE:
+		alternate(rounds)
+	}
+}
+
+// alternate orders rounds so that no category follows itself. It always continues with the
+// category that has the most rounds left, which avoids repetitions as long as possible.
+func alternate(rounds []*Round) {
+	remaining := slices.Clone(rounds)
+	var last string
+	for i := range rounds {
+		left := make(map[string]int)
+		for _, r := range remaining {
+			left[r.Category.ID]++
+		}
+		next := -1
+		for j, r := range remaining {
+			if r.Category.ID == last && len(left) > 1 {
+				continue
+			}
+			if next == -1 || left[r.Category.ID] > left[remaining[next].Category.ID] {
+				next = j
+			}
+		}
+		rounds[i] = remaining[next]
+		last = rounds[i].Category.ID
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+}
+
+// setDifficulty sets the difficulty of all rounds. The difficulty from the sheet is used if set,
+// otherwise the measured correct rate of chat, if measured is set and the question was asked often
+// enough. It reports whether any measured difficulty was used.
+func setDifficulty(rounds []*Round, measured bool) (usedMeasured bool, err error) {
+	var stats map[string]database.QuestionStats
+	if measured {
+		ids := make([]string, 0, len(rounds))
+		for _, r := range rounds {
+			if r.question != nil {
+				ids = append(ids, r.question.ID())
+			}
+		}
+		stats, err = database.GetQuestionStats(ids...)
+		if err != nil {
+			err = fmt.Errorf("get question stats: %v", err)
+		}
+	}
+
+	for _, r := range rounds {
+		r.Difficulty = defaultDifficulty
+		if r.question == nil {
+			continue
+		}
+		if r.question.Difficulty != 0 {
+			r.Difficulty = float64(r.question.Difficulty)
+			continue
+		}
+		s := stats[r.question.ID()]
+		if s.Asked < minMeasuredRounds {
+			continue
+		}
+		correctRate := float64(s.Correct) / float64(s.Asked)
+		r.Difficulty = MinDifficulty + (1-correctRate)*(MaxDifficulty-MinDifficulty)
+		usedMeasured = true
+	}
+	return usedMeasured, err
+}
+
+// recordResult saves in the background whether chat answered the current round correctly, so it
+// can be used as measured difficulty. Voided rounds and rounds without chat votes are not saved.
+// The caller must hold g.mu.
+func (g *Game) recordResult() {
+	round := g.round()
+	if round == nil || round.question == nil || g.connection == nil || g.connection.userID == "" {
+		return
+	}
+	if round.Voided || len(g.chatVotes) == 0 {
+		return
+	}
+	questionID, correct := round.question.ID(), g.roundScore.ChatWon > 0
+	go func() {
+		err := database.AddQuestionResult(questionID, correct)
+		if err != nil {
+			log.Printf("Error saving result of question %s: %v", questionID, err)
+		}
+	}()
+}
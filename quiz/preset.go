@@ -0,0 +1,189 @@
+package quiz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"quiz_backend/database"
+)
+
+// ErrPresetNotFound is returned when the user has no preset with a given ID.
+var ErrPresetNotFound = errors.New("preset not found")
+
+// Preset is a named game setup saved by a streamer. Warnings list the categories of the preset that
+// were removed from the catalogue or have less questions than the preset wants.
+type Preset struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Settings  GameSettings `json:"settings"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Warnings  []string     `json:"warnings,omitempty"`
+}
+
+// presetFromDB converts a preset from the database and checks it against the current catalogue.
+func presetFromDB(p database.Preset) (Preset, error) {
+	preset := Preset{
+		ID:        p.ID,
+		Name:      p.Name,
+		UpdatedAt: p.UpdatedAt,
+	}
+	err := json.Unmarshal(p.Settings, &preset.Settings)
+	if err != nil {
+		return Preset{}, fmt.Errorf("preset '%s' has invalid settings: %v", p.ID, err)
+	}
+	preset.Warnings = preset.Settings.catalogueWarnings()
+	return preset, nil
+}
+
+// Presets returns all presets of c.
+func (c *Connection) Presets() ([]Preset, error) {
+	dbPresets, err := database.GetPresets(c.userID)
+	if err != nil {
+		return nil, fmt.Errorf("get presets: %v", err)
+	}
+	presets := make([]Preset, 0, len(dbPresets))
+	for _, p := range dbPresets {
+		preset, err := presetFromDB(p)
+		if err != nil {
+			log.Printf("Error getting presets of user %s: %v", c.userID, err)
+			continue
+		}
+		presets = append(presets, preset)
+	}
+	return presets, nil
+}
+
+// GetPreset returns the preset of c with the given ID.
+func (c *Connection) GetPreset(presetID string) (Preset, error) {
+	p, err := database.GetPreset(c.userID, presetID)
+	if err != nil {
+		return Preset{}, fmt.Errorf("get preset: %v", err)
+	}
+	if p == nil {
+		return Preset{}, fmt.Errorf("%w: '%s'", ErrPresetNotFound, presetID)
+	}
+	return presetFromDB(*p)
+}
+
+// SavePreset saves the json encoded preset in data, which needs a "name" and the "settings" of the
+// game. If presetID is empty a new preset is created, otherwise the existing one is replaced.
+func (c *Connection) SavePreset(presetID string, data []byte) (Preset, error) {
+	var presetData struct {
+		Name     string          `json:"name"`
+		Settings json.RawMessage `json:"settings"`
+	}
+	err := json.Unmarshal(data, &presetData)
+	if err != nil {
+		return Preset{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	presetData.Name = strings.TrimSpace(presetData.Name)
+	if presetData.Name == "" {
+		return Preset{}, fmt.Errorf("%w: preset needs a name", ErrInvalidArgument)
+	}
+	// validate a copy, so that defaults are not saved with the preset
+	var settings GameSettings
+	err = json.Unmarshal(presetData.Settings, &settings)
+	if err != nil {
+		return Preset{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if err = settings.validate(); err != nil {
+		return Preset{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	if presetID == "" {
+		presetID, err = database.CreatePreset(c.userID, presetData.Name, presetData.Settings)
+	} else {
+		var found bool
+		found, err = database.UpdatePreset(c.userID, presetID, presetData.Name, presetData.Settings)
+		if err == nil && !found {
+			return Preset{}, fmt.Errorf("%w: '%s'", ErrPresetNotFound, presetID)
+		}
+	}
+	if err != nil {
+		return Preset{}, fmt.Errorf("save preset: %v", err)
+	}
+	return c.GetPreset(presetID)
+}
+
+// DeletePreset deletes the preset of c with the given ID.
+func (c *Connection) DeletePreset(presetID string) error {
+	found, err := database.DeletePreset(c.userID, presetID)
+	if err != nil {
+		return fmt.Errorf("delete preset: %v", err)
+	}
+	if !found {
+		return fmt.Errorf("%w: '%s'", ErrPresetNotFound, presetID)
+	}
+	return nil
+}
+
+// catalogueWarnings checks the groups and categories of s against the current catalogue.
+func (s GameSettings) catalogueWarnings() (warnings []string) {
+	for _, groupID := range slices.Sorted(maps.Keys(s.Groups)) {
+		groupSettings := s.Groups[groupID]
+		group := Categories.GetGroupByID(groupID)
+		if group.ID == "" {
+			warnings = append(warnings, fmt.Sprintf("group '%s' was removed", groupID))
+			continue
+		}
+
+		var questions int
+		for _, category := range group.Categories {
+			questions += len(category.Pool)
+		}
+		if groupSettings.Random > questions {
+			warnings = append(warnings, fmt.Sprintf("group '%s' has only %d questions for %d random rounds", groupID, questions, groupSettings.Random))
+		}
+
+		for _, categoryID := range slices.Sorted(maps.Keys(groupSettings.Categories)) {
+			amount := groupSettings.Categories[categoryID]
+			category := Categories.GetCategoryByID(categoryID)
+			if category.ID == "" {
+				warnings = append(warnings, fmt.Sprintf("category '%s' was removed", categoryID))
+			} else if len(category.Pool) < amount {
+				warnings = append(warnings, fmt.Sprintf("category '%s' has only %d questions for %d rounds", categoryID, len(category.Pool), amount))
+			}
+		}
+	}
+	return warnings
+}
+
+// removeMissingCategories removes all groups and categories from s that are not in the current
+// catalogue anymore.
+func (s *GameSettings) removeMissingCategories() {
+	for groupID, group := range s.Groups {
+		if Categories.GetGroupByID(groupID).ID == "" {
+			delete(s.Groups, groupID)
+			continue
+		}
+		for categoryID := range group.Categories {
+			if Categories.GetCategoryByID(categoryID).ID == "" {
+				delete(group.Categories, categoryID)
+			}
+		}
+	}
+}
+
+// override returns s as json with every top-level key of the json object data replaced by its
+// value in data. Keys are replaced as a whole, so e.g. "groups" in data replaces all groups of s
+// instead of being merged with them.
+func (s GameSettings) override(data []byte) ([]byte, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var settings, overrides map[string]json.RawMessage
+	if err = json.Unmarshal(b, &settings); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &overrides); err != nil {
+		return nil, err
+	}
+	maps.Copy(settings, overrides)
+	return json.Marshal(settings)
+}
@@ -0,0 +1,51 @@
+package quiz
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+)
+
+func TestPresetOverride(t *testing.T) {
+	var preset GameSettings
+	err := json.Unmarshal([]byte(`{
+		"groups": {"geo": {"random": 2}, "history": {"categories": {"history-1": 3}}},
+		"round_duration": 30,
+		"category_durations": {"history-1": 60},
+		"jokers": {"fifty_fifty": 2, "ask_chat": 1, "swap": 1},
+		"round_order": "blocks"
+	}`), &preset)
+	if err != nil {
+		t.Fatalf("unmarshal preset: %v", err)
+	}
+
+	data, err := preset.override([]byte(`{
+		"preset": "x",
+		"groups": {"science": {"random": 1}},
+		"category_durations": {},
+		"jokers": {"swap": 2}
+	}`))
+	if err != nil {
+		t.Fatalf("override: %v", err)
+	}
+	var settings GameSettings
+	if err = json.Unmarshal(data, &settings); err != nil {
+		t.Fatalf("unmarshal settings: %v", err)
+	}
+
+	if got := settings.Groups; len(got) != 1 || got["science"].Random != 1 {
+		t.Errorf("groups = %v, want only the overridden group", got)
+	}
+	if len(settings.CategoryDurations) != 0 {
+		t.Errorf("category durations = %v, want none", settings.CategoryDurations)
+	}
+	if got, want := *settings.Jokers, (Jokers{Swap: 2}); got != want {
+		t.Errorf("jokers = %+v, want %+v", got, want)
+	}
+	if settings.RoundDuration != 30 || settings.RoundOrder != preset.RoundOrder {
+		t.Errorf("settings without override changed: round duration %d, round order %s", settings.RoundDuration, settings.RoundOrder)
+	}
+	if !maps.Equal(preset.CategoryDurations, map[string]int{"history-1": 60}) || len(preset.Groups) != 2 {
+		t.Errorf("preset changed: %+v", preset)
+	}
+}
@@ -0,0 +1,275 @@
+package quiz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"quiz_backend/database"
+
+	"github.com/spf13/viper"
+)
+
+// ErrReportNotFound is returned when there is no report with a given ID.
+var ErrReportNotFound = errors.New("report not found")
+
+// maxReportComment is the maximum length of a report comment in characters. Longer comments are
+// cut off.
+const maxReportComment = 500
+
+// ReportStatus is the state of a question report in the moderation queue.
+type ReportStatus uint8
+
+const (
+	// REPORTOPEN is a new report nobody looked at yet.
+	REPORTOPEN ReportStatus = iota
+	// REPORTTRIAGED is a report an editor confirmed, but the question is not fixed yet.
+	REPORTTRIAGED
+	// REPORTRESOLVED is a report whose question was fixed.
+	REPORTRESOLVED
+	// REPORTDISMISSED is a report that turned out to be wrong.
+	REPORTDISMISSED
+)
+
+func (s ReportStatus) String() string {
+	switch s {
+	case REPORTOPEN:
+		return "open"
+	case REPORTTRIAGED:
+		return "triaged"
+	case REPORTRESOLVED:
+		return "resolved"
+	case REPORTDISMISSED:
+		return "dismissed"
+	default:
+		return fmt.Sprintf("ReportStatus(%d)", s)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The status is encoded as its string representation.
+func (s ReportStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (s *ReportStatus) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	status, ok := ParseReportStatus(str)
+	if !ok {
+		return fmt.Errorf("unknown report status '%s'", str)
+	}
+	*s = status
+	return nil
+}
+
+// ParseReportStatus returns the status with the string representation s. It reports whether
+// there is such a status.
+func ParseReportStatus(s string) (ReportStatus, bool) {
+	for status := REPORTOPEN; status <= REPORTDISMISSED; status++ {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// Report is a question flagged as wrong by a viewer or the streamer. Round is the round of the game
+// the question was asked in, UserID the streamer of that game. Note is left by the editor who
+// triaged or resolved the report.
+type Report struct {
+	ID         string       `json:"id"`
+	QuestionID string       `json:"question_id"`
+	Question   string       `json:"question"`
+	CategoryID string       `json:"category_id"`
+	Round      int          `json:"round"`
+	UserID     string       `json:"user_id"`
+	Reporter   string       `json:"reporter"`
+	Comment    string       `json:"comment,omitempty"`
+	Status     ReportStatus `json:"status"`
+	Note       string       `json:"note,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+// reportFromDB converts a report from the database.
+func reportFromDB(r database.Report) Report {
+	status, ok := ParseReportStatus(r.Status)
+	if !ok {
+		log.Printf("Warn: report '%s' has unknown status '%s'", r.ID, r.Status)
+	}
+	return Report{
+		ID:         r.ID,
+		QuestionID: r.QuestionID,
+		Question:   r.Question,
+		CategoryID: r.CategoryID,
+		Round:      r.Round,
+		UserID:     r.UserID,
+		Reporter:   r.Reporter,
+		Comment:    r.Comment,
+		Status:     status,
+		Note:       r.Note,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+}
+
+// reportedEvent is the payload of a QUESTION_REPORTED event.
+type reportedEvent struct {
+	Current  int    `json:"current_round"`
+	Reporter string `json:"reporter"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// newReportedEvent returns the QUESTION_REPORTED event for r.
+func newReportedEvent(r database.Report) reportedEvent {
+	return reportedEvent{
+		Current:  r.Round,
+		Reporter: r.Reporter,
+		Comment:  r.Comment,
+	}
+}
+
+// reportQuestion flags the question of the current round on behalf of reporter. Every reporter
+// can report each round once. The report is returned to be saved and sent as event by the caller,
+// which must hold g.mu.
+func (g *Game) reportQuestion(reporter, comment string) (database.Report, error) {
+	if err := g.checkState("report the question", STATEQUESTION, STATEVOTINGCLOSED, STATEREVEAL); err != nil {
+		return database.Report{}, err
+	}
+	round := g.round()
+	if round == nil || round.question == nil {
+		return database.Report{}, fmt.Errorf("%w: no question to report", ErrInvalidState)
+	}
+	if g.reporters[strings.ToLower(reporter)] {
+		return database.Report{}, fmt.Errorf("%w: '%s' already reported this round", ErrInvalidState, reporter)
+	}
+	g.reporters[strings.ToLower(reporter)] = true
+
+	comment = strings.TrimSpace(comment)
+	if runes := []rune(comment); len(runes) > maxReportComment {
+		comment = string(runes[:maxReportComment])
+	}
+	return database.Report{
+		QuestionID: round.question.ID(),
+		Question:   round.question.Question.Text,
+		CategoryID: round.Category.ID,
+		Round:      g.Current,
+		UserID:     g.connection.userID,
+		Reporter:   reporter,
+		Comment:    comment,
+		Status:     REPORTOPEN.String(),
+	}, nil
+}
+
+// chatReport handles a "!report [comment]" message of the viewer username. It reports whether msg
+// was a report command and returns the event to send for it, which is nil if the report was not
+// accepted. Reports are saved in the background. The caller must hold g.mu.
+func (g *Game) chatReport(username, msg string) (*reportedEvent, bool) {
+	command, comment, _ := strings.Cut(strings.TrimSpace(msg), " ")
+	if !strings.EqualFold(command, "!report") {
+		return nil, false
+	}
+	report, err := g.reportQuestion(username, comment)
+	if err != nil {
+		return nil, true
+	}
+	go func() {
+		_, err := database.AddReport(report)
+		if err != nil {
+			log.Printf("Error saving report of %s: %v", username, err)
+		}
+	}()
+	event := newReportedEvent(report)
+	return &event, true
+}
+
+// Report flags the question of the current round on behalf of the streamer with an optional
+// comment.
+func (g *Game) Report(comment string) (Report, error) {
+	g.mu.Lock()
+	reporter := g.connection.channel
+	if reporter == "" {
+		reporter = "streamer"
+	}
+	report, err := g.reportQuestion(reporter, comment)
+	g.mu.Unlock()
+	if err != nil {
+		return Report{}, err
+	}
+	g.connection.sendEvent("QUESTION_REPORTED", newReportedEvent(report))
+
+	report.ID, err = database.AddReport(report)
+	if err != nil {
+		return Report{}, fmt.Errorf("save report: %v", err)
+	}
+	return GetReport(report.ID)
+}
+
+// Reports returns all reports with one of the given statuses, or all reports if no status is given.
+func Reports(statuses ...ReportStatus) ([]Report, error) {
+	names := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		names = append(names, s.String())
+	}
+	dbReports, err := database.GetReports(names...)
+	if err != nil {
+		return nil, fmt.Errorf("get reports: %v", err)
+	}
+	reports := make([]Report, 0, len(dbReports))
+	for _, r := range dbReports {
+		reports = append(reports, reportFromDB(r))
+	}
+	return reports, nil
+}
+
+// GetReport returns the report with the given ID.
+func GetReport(reportID string) (Report, error) {
+	r, err := database.GetReport(reportID)
+	if err != nil {
+		return Report{}, fmt.Errorf("get report: %v", err)
+	}
+	if r == nil {
+		return Report{}, fmt.Errorf("%w: '%s'", ErrReportNotFound, reportID)
+	}
+	return reportFromDB(*r), nil
+}
+
+// UpdateReport sets the status of the report with the given ID, together with a note of the editor.
+func UpdateReport(reportID string, status ReportStatus, note string) (Report, error) {
+	found, err := database.UpdateReport(reportID, status.String(), strings.TrimSpace(note))
+	if err != nil {
+		return Report{}, fmt.Errorf("update report: %v", err)
+	}
+	if !found {
+		return Report{}, fmt.Errorf("%w: '%s'", ErrReportNotFound, reportID)
+	}
+	return GetReport(reportID)
+}
+
+// excludeReported blocks all questions that were reported by at least the amount of distinct
+// reporters set by "quiz.report_threshold" in the config. Only reports an editor triaged are
+// counted, so open reports alone can't hide a question. A threshold of 0 disables the exclusion.
+func (h *questionHistory) excludeReported() error {
+	threshold := viper.GetInt("quiz.report_threshold")
+	if threshold <= 0 {
+		return nil
+	}
+	counts, err := database.CountReports(REPORTTRIAGED.String())
+	if err != nil {
+		return fmt.Errorf("count reports: %v", err)
+	}
+	if h.blocked == nil {
+		h.blocked = make(map[string]bool)
+	}
+	for questionID, n := range counts {
+		if n >= threshold {
+			h.blocked[questionID] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package quiz
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// setTestCategories replaces the catalogue with two groups of generated multiple choice questions
+// for the duration of the test.
+func setTestCategories(t *testing.T) {
+	t.Helper()
+	prev := Categories
+	t.Cleanup(func() { Categories = prev })
+
+	Categories = make(categoryGroups)
+	for g, groupID := range []string{"geo", "history"} {
+		group := CategoryGroup{CategoryGroupDefinition: CategoryGroupDefinition{ID: groupID, Title: groupID}}
+		for c := 0; c < 3; c++ {
+			category := Category{CategoryDefinition: CategoryDefinition{
+				ID:    fmt.Sprintf("%s-%d", groupID, c),
+				Title: fmt.Sprintf("%s %d", groupID, c),
+			}}
+			for q := 0; q < 10; q++ {
+				question := &Question{
+					Question:   DisplayableContent{Text: fmt.Sprintf("%s question %d", category.ID, q)},
+					Correct:    []DisplayableContent{{Text: "correct"}},
+					categoryID: category.ID,
+				}
+				for w := 0; w < 5; w++ {
+					question.Wrong = append(question.Wrong, DisplayableContent{Text: fmt.Sprintf("wrong %d", w)})
+				}
+				category.Pool = append(category.Pool, question)
+			}
+			group.Categories = append(group.Categories, category)
+		}
+		Categories[g] = group
+	}
+}
+
+// newGame creates a game with the given round order and seed, which may be nil, and returns it.
+func newGame(t *testing.T, roundOrder string, seed *int64) *Game {
+	t.Helper()
+	seedJSON, _ := json.Marshal(seed)
+	data := fmt.Sprintf(`{
+		"groups": {
+			"geo": {"random": 2, "categories": {"geo-0": 3}},
+			"history": {"categories": {"history-1": 2, "history-2": 2}}
+		},
+		"round_duration": 30,
+		"answer_count": 4,
+		"round_order": %q,
+		"seed": %s
+	}`, roundOrder, seedJSON)
+	c := &Connection{}
+	if err := c.NewGame([]byte(data)); err != nil {
+		t.Fatalf("NewGame: %v", err)
+	}
+	return c.Game
+}
+
+// newSeededGame creates a game with the given round order and seed and returns it.
+func newSeededGame(t *testing.T, seed int64, roundOrder string) *Game {
+	t.Helper()
+	return newGame(t, roundOrder, &seed)
+}
+
+// roundsJSON returns the rounds of g as they are sent to the client.
+func roundsJSON(t *testing.T, g *Game) string {
+	t.Helper()
+	b, err := json.Marshal(g.Rounds)
+	if err != nil {
+		t.Fatalf("marshal rounds: %v", err)
+	}
+	return string(b)
+}
+
+func TestSeedRounds(t *testing.T) {
+	setTestCategories(t)
+
+	for _, order := range []string{"random", "difficulty", "blocks", "alternate"} {
+		t.Run(order, func(t *testing.T) {
+			a := newSeededGame(t, 42, order)
+			b := newSeededGame(t, 42, order)
+			if a.Summary.Seed == nil || *a.Summary.Seed != 42 {
+				t.Errorf("summary seed = %v, want 42", a.Summary.Seed)
+			}
+			if len(a.Rounds) != 9 {
+				t.Fatalf("got %d rounds, want 9", len(a.Rounds))
+			}
+			if got, want := roundsJSON(t, b), roundsJSON(t, a); got != want {
+				t.Errorf("same seed gave different rounds:\n%s\n%s", got, want)
+			}
+		})
+	}
+
+	a := newSeededGame(t, 42, "random")
+	for seed := int64(1); seed <= 3; seed++ {
+		if roundsJSON(t, newSeededGame(t, seed, "random")) != roundsJSON(t, a) {
+			return
+		}
+	}
+	t.Error("different seeds always gave the same rounds")
+}
+
+func TestSeedRematch(t *testing.T) {
+	setTestCategories(t)
+
+	// without a streamer there is no history, so the seed of a game without one reproduces it. The
+	// difficulty order is left out, because it reads the measured difficulties from the database.
+	for _, order := range []string{"random", "blocks", "alternate"} {
+		t.Run(order, func(t *testing.T) {
+			a := newGame(t, order, nil)
+			if a.Summary.Seed == nil {
+				t.Fatal("summary has no seed")
+			}
+			b := newGame(t, order, a.Summary.Seed)
+			if got, want := roundsJSON(t, b), roundsJSON(t, a); got != want {
+				t.Errorf("rematch gave different rounds:\n%s\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSeedTieBreak(t *testing.T) {
+	setTestCategories(t)
+
+	tieBreaks := func(g *Game) []int {
+		g.Consensus.Tie = TIERANDOM
+		var answers []int
+		for range g.Rounds {
+			g.tieBreaks = make(map[string]int)
+			answer, tied, _, decision := g.Consensus.decideChoice(g.tieBreak(""), []float64{2, 2, 0, 2}, 6, 1)
+			if decision != DECISIONTIERANDOM || !slices.Equal(tied, []int{1, 2, 4}) {
+				t.Fatalf("got decision %s with tied %v, want a random tie of 1, 2 and 4", decision, tied)
+			}
+			// scoring the round again, e.g. after a correction, keeps the answer
+			again, _, _, _ := g.Consensus.decideChoice(g.tieBreak(""), []float64{2, 2, 0, 2}, 6, 2)
+			if again != answer {
+				t.Fatalf("tie-break changed from %d to %d when scored again", answer, again)
+			}
+			answers = append(answers, answer)
+		}
+		return answers
+	}
+
+	a := tieBreaks(newSeededGame(t, 7, "random"))
+	b := tieBreaks(newSeededGame(t, 7, "random"))
+	if !slices.Equal(a, b) {
+		t.Errorf("same seed gave different tie-breaks: %v and %v", a, b)
+	}
+}
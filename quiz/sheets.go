@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"quiz_backend/google"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"google.golang.org/api/sheets/v4"
 )
@@ -99,6 +101,7 @@ func parseSheet(s *sheets.Sheet) Category {
 			continue
 		}
 		if question != nil {
+			question.categoryID = category.ID
 			category.Pool = append(category.Pool, question)
 		}
 	}
@@ -108,6 +111,7 @@ func parseSheet(s *sheets.Sheet) Category {
 
 func getQuestionFromRow(row *sheets.RowData) (qq *Question, err error) {
 	qq = &Question{}
+	var tolerance string
 	for cellNum, cell := range row.Values {
 		// skip empty cells
 		if cell == nil {
@@ -121,6 +125,40 @@ func getQuestionFromRow(row *sheets.RowData) (qq *Question, err error) {
 		// only read contents of the first cell and save it as the question
 		if cellNum == 0 {
 			qq.Question = cellContent
+			parseQuestionNote(cell.Note, qq)
+			continue
+		}
+
+		// hints start with a question mark and are revealed during the round, in the order of the
+		// cells
+		if hint, ok := strings.CutPrefix(cellContent.Text, "?"); ok {
+			if hint = strings.TrimSpace(hint); hint != "" {
+				qq.Hints = append(qq.Hints, DisplayableContent{Text: hint})
+			}
+			continue
+		}
+
+		// colors don't matter for ordering and matching questions
+		switch qq.Type {
+		case QUESTIONORDER:
+			qq.Items = append(qq.Items, cellContent)
+			continue
+		case QUESTIONMATCH:
+			left, right, found := strings.Cut(cellContent.Text, "=")
+			if !found || strings.TrimSpace(left) == "" || strings.TrimSpace(right) == "" {
+				log.Printf("Warn: in question '%s' pair %d ('%s'): need format 'left = right'", qq.Question.Text, cellNum, cellContent.Text)
+				continue
+			}
+			qq.Pairs = append(qq.Pairs, MatchPair{
+				Left:  DisplayableContent{Text: strings.TrimSpace(left)},
+				Right: DisplayableContent{Text: strings.TrimSpace(right)},
+			})
+			continue
+		}
+
+		// tolerance of an estimation question
+		if strings.HasPrefix(cellContent.Text, "±") {
+			tolerance = cellContent.Text
 			continue
 		}
 
@@ -144,9 +182,39 @@ func getQuestionFromRow(row *sheets.RowData) (qq *Question, err error) {
 		}
 		return nil, fmt.Errorf("missing question")
 	}
+	switch qq.Type {
+	case QUESTIONORDER:
+		if len(qq.Items) < 2 {
+			return nil, fmt.Errorf("need at least two items to order")
+		}
+		return qq, nil
+	case QUESTIONMATCH:
+		if len(qq.Pairs) < 2 {
+			return nil, fmt.Errorf("need at least two pairs to match")
+		}
+		return qq, nil
+	}
 	if len(qq.Correct) == 0 {
 		return nil, fmt.Errorf("need at least one correct answer")
 	}
+	// free text and estimation questions have to be marked explicitly, so unfinished rows without
+	// incorrect answers are not used
+	switch {
+	case qq.Type == QUESTIONESTIMATE || tolerance != "":
+		if len(qq.Wrong) > 0 {
+			return nil, fmt.Errorf("estimation question must not have incorrect answers")
+		}
+		if err = parseEstimate(qq, tolerance); err != nil {
+			return nil, err
+		}
+		return qq, nil
+	case qq.Type == QUESTIONTEXT:
+		// all correct answers are the accepted spellings of a free text answer
+		if len(qq.Wrong) > 0 {
+			return nil, fmt.Errorf("free text question must not have incorrect answers")
+		}
+		return qq, nil
+	}
 	if len(qq.Wrong) == 0 {
 		return nil, fmt.Errorf("need at least one incorrect answer")
 	}
@@ -154,6 +222,92 @@ func getQuestionFromRow(row *sheets.RowData) (qq *Question, err error) {
 	return qq, nil
 }
 
+// parseQuestionNote reads the options set by the note of a question cell into qq. The note consists
+// of options separated by semicolons or new lines. Question types that can't be told apart by the
+// answers alone need to be set explicitly:
+//
+//   - "multi": all correct answers are shown and have to be selected ("select all that apply")
+//   - "order": the answers are listed in the correct order and have to be put into it again
+//   - "match": every answer is a pair "left = right" and the right sides have to be matched to the
+//     left ones
+//   - "text": the correct answers are the accepted spellings of a free text answer
+//   - "estimate": the only correct answer is a number to estimate, also set by a tolerance cell
+//     starting with "±"
+//
+// Without a type the question is [QUESTIONCHOICE].
+// Additionally "answers=N" sets the amount of answers shown for this question, "difficulty=N" its
+// difficulty from 1 (easy) to 5 (hard) and "time=N" its time limit in seconds.
+func parseQuestionNote(note string, qq *Question) {
+	options := strings.FieldsFunc(note, func(r rune) bool { return r == ';' || r == '\n' })
+	for _, option := range options {
+		option = strings.ToLower(strings.TrimSpace(option))
+		if value, ok := strings.CutPrefix(option, "answers="); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < MinAnswerCount || n > MaxAnswerCount {
+				log.Printf("Warn: in question '%s': invalid answer count '%s'", qq.Question.Text, value)
+				continue
+			}
+			qq.AnswerCount = n
+			continue
+		}
+		if value, ok := strings.CutPrefix(option, "difficulty="); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < MinDifficulty || n > MaxDifficulty {
+				log.Printf("Warn: in question '%s': invalid difficulty '%s'", qq.Question.Text, value)
+				continue
+			}
+			qq.Difficulty = n
+			continue
+		}
+		if value, ok := strings.CutPrefix(option, "time="); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n <= 0 {
+				log.Printf("Warn: in question '%s': invalid time limit '%s'", qq.Question.Text, value)
+				continue
+			}
+			qq.TimeLimit = n
+			continue
+		}
+
+		switch option {
+		case "multi":
+			qq.Type = QUESTIONMULTI
+		case "order":
+			qq.Type = QUESTIONORDER
+		case "match":
+			qq.Type = QUESTIONMATCH
+		case "text":
+			qq.Type = QUESTIONTEXT
+		case "estimate":
+			qq.Type = QUESTIONESTIMATE
+		}
+	}
+}
+
+// parseEstimate turns qq into an estimation question. The only correct answer of qq must be a number
+// with an optional unit, e.g. "330 m". The tolerance is optional and parsed with [parseTolerance].
+func parseEstimate(qq *Question, tolerance string) error {
+	if len(qq.Correct) != 1 {
+		return fmt.Errorf("estimation question needs exactly one correct answer")
+	}
+	solution, unit, ok := ParseNumber(qq.Correct[0].Text)
+	if !ok {
+		return fmt.Errorf("solution of estimation question must be a number")
+	}
+
+	qq.Type = QUESTIONESTIMATE
+	qq.Solution = solution
+	qq.Unit = unit
+	qq.Tolerance = defaultEstimateTolerance
+	if tolerance != "" {
+		qq.Tolerance, ok = parseTolerance(tolerance, solution)
+		if !ok {
+			return fmt.Errorf("invalid tolerance '%s'", tolerance)
+		}
+	}
+	return nil
+}
+
 func getColorFromCell(cell *sheets.CellData) (color *sheets.Color, err error) {
 	var format *sheets.CellFormat
 	if cell.EffectiveFormat != nil {
@@ -0,0 +1,71 @@
+package quiz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// GameState is the phase a game is currently in. A game always starts in STATELOBBY and moves
+// through the states in the following order:
+//
+//	lobby -> question -> voting closed -> reveal -> question -> ... -> reveal -> finished
+type GameState uint8
+
+const (
+	// STATELOBBY is the state of a newly created game before the first round was started.
+	STATELOBBY GameState = iota
+	// STATEQUESTION is the state while a question is shown and votes are accepted.
+	STATEQUESTION
+	// STATEVOTINGCLOSED is the state after the round timer ran out. Votes are not accepted anymore,
+	// but the correct answer is not revealed yet.
+	STATEVOTINGCLOSED
+	// STATEREVEAL is the state while the correct answer and the round summary are shown.
+	STATEREVEAL
+	// STATEFINISHED is the state after the last round was revealed.
+	STATEFINISHED
+)
+
+var (
+	// ErrInvalidState is returned when an action is not allowed in the current state of the game.
+	ErrInvalidState = errors.New("invalid game state")
+	// ErrAlreadyVoted is returned when the streamer tries to vote a second time in a round.
+	ErrAlreadyVoted = errors.New("already voted")
+	// ErrInvalidVote is returned when a vote is not a valid option for the current round.
+	ErrInvalidVote = errors.New("invalid vote")
+	// ErrInvalidArgument is returned when a parameter for an action on a game is out of range.
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+func (s GameState) String() string {
+	switch s {
+	case STATELOBBY:
+		return "lobby"
+	case STATEQUESTION:
+		return "question"
+	case STATEVOTINGCLOSED:
+		return "voting_closed"
+	case STATEREVEAL:
+		return "reveal"
+	case STATEFINISHED:
+		return "finished"
+	default:
+		return fmt.Sprintf("GameState(%d)", s)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The state is encoded as its string representation.
+func (s GameState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// checkState returns an [ErrInvalidState] error if the game is in none of the given states. action
+// is used to describe the failed action in the error message.
+func (g *Game) checkState(action string, allowed ...GameState) error {
+	for _, s := range allowed {
+		if g.State == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: cannot %s while in state '%s'", ErrInvalidState, action, g.State)
+}
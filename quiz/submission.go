@@ -0,0 +1,471 @@
+package quiz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"quiz_backend/database"
+)
+
+// ErrSubmissionNotFound is returned when there is no submission with a given ID.
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+const (
+	// maxSubmissionText is the maximum length of the question and each answer of a submission in
+	// characters.
+	maxSubmissionText = 300
+	// maxSubmissionAnswers is the maximum amount of answers of a submission.
+	maxSubmissionAnswers = 2 * MaxAnswerCount
+	// submitCooldown is the time a viewer has to wait between two submissions in chat.
+	submitCooldown = time.Minute
+)
+
+var (
+	// lastSubmission is the time of the last chat submission of each viewer, in lowercase
+	lastSubmission   = make(map[string]time.Time)
+	lastSubmissionMu sync.Mutex
+)
+
+// SubmissionStatus is the state of a community submission in the review queue.
+type SubmissionStatus uint8
+
+const (
+	// SUBMISSIONPENDING is a submission that was not reviewed yet.
+	SUBMISSIONPENDING SubmissionStatus = iota
+	// SUBMISSIONACCEPTED is a submission that is used as question in its category.
+	SUBMISSIONACCEPTED
+	// SUBMISSIONREJECTED is a submission that is not used.
+	SUBMISSIONREJECTED
+)
+
+func (s SubmissionStatus) String() string {
+	switch s {
+	case SUBMISSIONPENDING:
+		return "pending"
+	case SUBMISSIONACCEPTED:
+		return "accepted"
+	case SUBMISSIONREJECTED:
+		return "rejected"
+	default:
+		return fmt.Sprintf("SubmissionStatus(%d)", s)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The status is encoded as its string representation.
+func (s SubmissionStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (s *SubmissionStatus) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	status, ok := ParseSubmissionStatus(str)
+	if !ok {
+		return fmt.Errorf("unknown submission status '%s'", str)
+	}
+	*s = status
+	return nil
+}
+
+// ParseSubmissionStatus returns the status with the string representation s. It reports whether
+// there is such a status.
+func ParseSubmissionStatus(s string) (SubmissionStatus, bool) {
+	for status := SUBMISSIONPENDING; status <= SUBMISSIONREJECTED; status++ {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// Submission is a question submitted by a viewer or streamer. Accepted submissions are added to
+// the category with CategoryID like a question from the sheet. Type is [QUESTIONCHOICE], which
+// needs correct and wrong answers, or [QUESTIONTEXT] and [QUESTIONESTIMATE], which need only
+// correct answers.
+type Submission struct {
+	ID         string           `json:"id"`
+	Type       QuestionType     `json:"type"`
+	Question   string           `json:"question"`
+	Correct    []string         `json:"correct"`
+	Wrong      []string         `json:"wrong"`
+	CategoryID string           `json:"category_id,omitempty"`
+	Submitter  string           `json:"submitter"`
+	Channel    string           `json:"channel,omitempty"`
+	Status     SubmissionStatus `json:"status"`
+	Note       string           `json:"note,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// submissionFromDB converts a submission from the database.
+func submissionFromDB(s database.Submission) (Submission, error) {
+	status, ok := ParseSubmissionStatus(s.Status)
+	if !ok {
+		return Submission{}, fmt.Errorf("submission '%s' has unknown status '%s'", s.ID, s.Status)
+	}
+	questionType, ok := parseQuestionType(s.Type)
+	if !ok {
+		return Submission{}, fmt.Errorf("submission '%s' has unknown type '%s'", s.ID, s.Type)
+	}
+	sub := Submission{
+		ID:         s.ID,
+		Type:       questionType,
+		Question:   s.Question,
+		CategoryID: s.CategoryID,
+		Submitter:  s.Submitter,
+		Channel:    s.Channel,
+		Status:     status,
+		Note:       s.Note,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+	if err := json.Unmarshal(s.Correct, &sub.Correct); err != nil {
+		return Submission{}, fmt.Errorf("submission '%s' has invalid correct answers: %v", s.ID, err)
+	}
+	if err := json.Unmarshal(s.Wrong, &sub.Wrong); err != nil {
+		return Submission{}, fmt.Errorf("submission '%s' has invalid wrong answers: %v", s.ID, err)
+	}
+	return sub, nil
+}
+
+// toDB converts s for the database.
+func (s Submission) toDB() (database.Submission, error) {
+	correct, err := json.Marshal(s.Correct)
+	if err != nil {
+		return database.Submission{}, err
+	}
+	wrong, err := json.Marshal(s.Wrong)
+	if err != nil {
+		return database.Submission{}, err
+	}
+	return database.Submission{
+		ID:         s.ID,
+		Type:       s.Type.String(),
+		Question:   s.Question,
+		Correct:    correct,
+		Wrong:      wrong,
+		CategoryID: s.CategoryID,
+		Submitter:  s.Submitter,
+		Channel:    s.Channel,
+		Status:     s.Status.String(),
+		Note:       s.Note,
+	}, nil
+}
+
+// validate trims all texts of s and returns an error if s is not a valid question. Accepted
+// submissions also need an existing category.
+func (s *Submission) validate() error {
+	s.Question = strings.TrimSpace(s.Question)
+	if s.Question == "" {
+		return fmt.Errorf("missing question")
+	}
+	if utf8.RuneCountInString(s.Question) > maxSubmissionText {
+		return fmt.Errorf("question must not be longer than %d characters", maxSubmissionText)
+	}
+	for _, answers := range [][]string{s.Correct, s.Wrong} {
+		for i, answer := range answers {
+			answers[i] = strings.TrimSpace(answer)
+			if answers[i] == "" {
+				return fmt.Errorf("answers must not be empty")
+			}
+			if utf8.RuneCountInString(answers[i]) > maxSubmissionText {
+				return fmt.Errorf("answers must not be longer than %d characters", maxSubmissionText)
+			}
+		}
+	}
+	if len(s.Correct)+len(s.Wrong) > maxSubmissionAnswers {
+		return fmt.Errorf("at most %d answers are allowed", maxSubmissionAnswers)
+	}
+	if s.Status == SUBMISSIONACCEPTED && Categories.GetCategoryByID(s.CategoryID).ID == "" {
+		return fmt.Errorf("unknown category '%s'", s.CategoryID)
+	}
+	_, err := s.toQuestion()
+	return err
+}
+
+// toQuestion returns the question of s, credited to the submitter.
+func (s Submission) toQuestion() (*Question, error) {
+	q := &Question{
+		Question:     DisplayableContent{Text: s.Question},
+		SubmittedBy:  s.Submitter,
+		submissionID: s.ID,
+		categoryID:   s.CategoryID,
+	}
+	for _, answer := range s.Correct {
+		q.Correct = append(q.Correct, DisplayableContent{Text: answer})
+	}
+	for _, answer := range s.Wrong {
+		q.Wrong = append(q.Wrong, DisplayableContent{Text: answer})
+	}
+
+	if len(q.Correct) == 0 {
+		return nil, fmt.Errorf("need at least one correct answer")
+	}
+	switch s.Type {
+	case QUESTIONCHOICE:
+		if len(q.Wrong) == 0 {
+			return nil, fmt.Errorf("need at least one incorrect answer")
+		}
+	case QUESTIONTEXT, QUESTIONESTIMATE:
+		if len(q.Wrong) > 0 {
+			return nil, fmt.Errorf("%s question must not have incorrect answers", s.Type)
+		}
+		if s.Type == QUESTIONESTIMATE {
+			return q, parseEstimate(q, "")
+		}
+		q.Type = QUESTIONTEXT
+	default:
+		return nil, fmt.Errorf("%s questions can't be submitted", s.Type)
+	}
+	return q, nil
+}
+
+// parseSubmission parses a "!submit <question> | <correct answer> | <wrong answer> | ..." message.
+// Free text and estimation questions are marked by starting the question with "text:" or
+// "estimate:" and only have correct answers. It reports whether msg is a submit command at all.
+func parseSubmission(msg string) (s Submission, ok bool) {
+	command, text, _ := strings.Cut(strings.TrimSpace(msg), " ")
+	if !strings.EqualFold(command, "!submit") {
+		return Submission{}, false
+	}
+	parts := strings.Split(text, "|")
+	s.Question = strings.TrimSpace(parts[0])
+	if prefix, question, found := strings.Cut(s.Question, ":"); found {
+		switch strings.ToLower(prefix) {
+		case "text":
+			s.Type, s.Question = QUESTIONTEXT, question
+		case "estimate":
+			s.Type, s.Question = QUESTIONESTIMATE, question
+		}
+	}
+	if len(parts) > 1 {
+		s.Correct = parts[1:2]
+		s.Wrong = parts[2:]
+	}
+	return s, true
+}
+
+// chatSubmit handles a "!submit" message of the viewer username, see [parseSubmission]. Valid
+// submissions are saved in the background for review. It reports whether msg was a submit
+// command.
+func (c *Connection) chatSubmit(username, msg string) bool {
+	s, ok := parseSubmission(msg)
+	if !ok {
+		return false
+	}
+	if err := s.validate(); err != nil {
+		return true
+	}
+
+	lastSubmissionMu.Lock()
+	key := strings.ToLower(username)
+	if time.Since(lastSubmission[key]) < submitCooldown {
+		lastSubmissionMu.Unlock()
+		return true
+	}
+	lastSubmission[key] = time.Now()
+	lastSubmissionMu.Unlock()
+
+	s.Submitter = username
+	s.Channel = c.channel
+	go func() {
+		_, err := c.saveSubmission(s)
+		if err != nil {
+			log.Printf("Error saving submission of %s: %v", username, err)
+		}
+	}()
+	return true
+}
+
+// Submit saves the json encoded submission in data for review, which needs a "question" and the
+// "correct" and "wrong" answers. "category_id" is an optional suggestion for the editors. The
+// submission is credited to the channel of c.
+func (c *Connection) Submit(data []byte) (Submission, error) {
+	var s Submission
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return Submission{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	s.Status = SUBMISSIONPENDING
+	s.Submitter = c.channel
+	if s.Submitter == "" {
+		s.Submitter = "streamer"
+	}
+	s.Channel = c.channel
+	if err = s.validate(); err != nil {
+		return Submission{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	return c.saveSubmission(s)
+}
+
+// saveSubmission saves the new submission s and informs the streamer about it.
+func (c *Connection) saveSubmission(s Submission) (Submission, error) {
+	dbSubmission, err := s.toDB()
+	if err != nil {
+		return Submission{}, fmt.Errorf("save submission: %v", err)
+	}
+	s.ID, err = database.AddSubmission(dbSubmission)
+	if err != nil {
+		return Submission{}, fmt.Errorf("save submission: %v", err)
+	}
+	c.sendEvent("QUESTION_SUBMITTED", struct {
+		Submitter string `json:"submitter"`
+		Question  string `json:"question"`
+	}{
+		Submitter: s.Submitter,
+		Question:  s.Question,
+	})
+	return GetSubmission(s.ID)
+}
+
+// Submissions returns all submissions with one of the given statuses, or all submissions if no
+// status is given.
+func Submissions(statuses ...SubmissionStatus) ([]Submission, error) {
+	names := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		names = append(names, s.String())
+	}
+	dbSubmissions, err := database.GetSubmissions(names...)
+	if err != nil {
+		return nil, fmt.Errorf("get submissions: %v", err)
+	}
+	submissions := make([]Submission, 0, len(dbSubmissions))
+	for _, s := range dbSubmissions {
+		sub, err := submissionFromDB(s)
+		if err != nil {
+			log.Printf("Error getting submissions: %v", err)
+			continue
+		}
+		submissions = append(submissions, sub)
+	}
+	return submissions, nil
+}
+
+// GetSubmission returns the submission with the given ID.
+func GetSubmission(submissionID string) (Submission, error) {
+	s, err := database.GetSubmission(submissionID)
+	if err != nil {
+		return Submission{}, fmt.Errorf("get submission: %v", err)
+	}
+	if s == nil {
+		return Submission{}, fmt.Errorf("%w: '%s'", ErrSubmissionNotFound, submissionID)
+	}
+	return submissionFromDB(*s)
+}
+
+// ReviewSubmission applies the json encoded review in data to the submission with the given ID. It
+// needs the new "status" and may contain a "note" and edits of "question", "correct", "wrong" and
+// "category_id". Accepted submissions are added to their category right away.
+func ReviewSubmission(submissionID string, data []byte) (Submission, error) {
+	s, err := GetSubmission(submissionID)
+	if err != nil {
+		return Submission{}, err
+	}
+
+	var review struct {
+		Status     *SubmissionStatus `json:"status"`
+		Note       *string           `json:"note"`
+		Question   *string           `json:"question"`
+		Correct    []string          `json:"correct"`
+		Wrong      []string          `json:"wrong"`
+		CategoryID *string           `json:"category_id"`
+	}
+	err = json.Unmarshal(data, &review)
+	if err != nil {
+		return Submission{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if review.Status == nil {
+		return Submission{}, fmt.Errorf("%w: review needs a status", ErrInvalidArgument)
+	}
+	s.Status = *review.Status
+	if review.Note != nil {
+		s.Note = strings.TrimSpace(*review.Note)
+	}
+	if review.Question != nil {
+		s.Question = *review.Question
+	}
+	if review.Correct != nil {
+		s.Correct = review.Correct
+	}
+	if review.Wrong != nil {
+		s.Wrong = review.Wrong
+	}
+	if review.CategoryID != nil {
+		s.CategoryID = *review.CategoryID
+	}
+	if err = s.validate(); err != nil {
+		return Submission{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	dbSubmission, err := s.toDB()
+	if err != nil {
+		return Submission{}, fmt.Errorf("update submission: %v", err)
+	}
+	found, err := database.UpdateSubmission(dbSubmission)
+	if err != nil {
+		return Submission{}, fmt.Errorf("update submission: %v", err)
+	}
+	if !found {
+		return Submission{}, fmt.Errorf("%w: '%s'", ErrSubmissionNotFound, submissionID)
+	}
+	Categories = Categories.withSubmission(s)
+	return GetSubmission(submissionID)
+}
+
+// addSubmissions adds all accepted submissions to their categories in cg.
+func (cg categoryGroups) addSubmissions() error {
+	submissions, err := Submissions(SUBMISSIONACCEPTED)
+	if err != nil {
+		return err
+	}
+	for _, s := range submissions {
+		q, err := s.toQuestion()
+		if err != nil {
+			log.Printf("Warn: accepted submission '%s' is invalid: %v", s.ID, err)
+			continue
+		}
+		for _, group := range cg {
+			for i := range group.Categories {
+				if group.Categories[i].ID == s.CategoryID {
+					group.Categories[i].Pool = append(group.Categories[i].Pool, q)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// withSubmission returns a copy of cg, where the question of the submission s is removed from all
+// categories and added to its category again if it is accepted. cg itself is not changed, so games
+// that are created at the same time still see consistent categories.
+func (cg categoryGroups) withSubmission(s Submission) categoryGroups {
+	var question *Question
+	if s.Status == SUBMISSIONACCEPTED {
+		question, _ = s.toQuestion()
+	}
+
+	groups := make(categoryGroups, len(cg))
+	for color, group := range cg {
+		group.Categories = slices.Clone(group.Categories)
+		for i, category := range group.Categories {
+			category.Pool = slices.DeleteFunc(slices.Clone(category.Pool), func(q *Question) bool {
+				return q != nil && q.submissionID == s.ID
+			})
+			if question != nil && category.ID == s.CategoryID {
+				category.Pool = append(category.Pool, question)
+			}
+			group.Categories[i] = category
+		}
+		groups[color] = group
+	}
+	return groups
+}
@@ -0,0 +1,268 @@
+package quiz
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+)
+
+// MinTeams and MaxTeams are the limits of the amount of teams in team mode.
+const (
+	MinTeams = 2
+	MaxTeams = 8
+)
+
+// TeamAssignment defines how viewers get into a team.
+type TeamAssignment uint8
+
+const (
+	// TEAMJOIN lets viewers choose their team with "!join <team>". Votes of viewers without a team
+	// are ignored. This is the default.
+	TEAMJOIN TeamAssignment = iota
+	// TEAMHASH assigns viewers to a team by a hash of their username, so they always end up in the
+	// same team.
+	TEAMHASH
+)
+
+func (a TeamAssignment) String() string {
+	switch a {
+	case TEAMJOIN:
+		return "join"
+	case TEAMHASH:
+		return "hash"
+	default:
+		return fmt.Sprintf("TeamAssignment(%d)", a)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The assignment is encoded as its string representation.
+func (a TeamAssignment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. An empty string results in [TEAMJOIN].
+func (a *TeamAssignment) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	switch s {
+	case "", "join":
+		*a = TEAMJOIN
+	case "hash":
+		*a = TEAMHASH
+	default:
+		return fmt.Errorf("unknown team assignment '%s'", s)
+	}
+	return nil
+}
+
+// TeamMode splits chat into teams that vote and score separately.
+type TeamMode struct {
+	// Teams are the names of the teams, like "red" and "blue".
+	Teams  []string       `json:"teams"`
+	Assign TeamAssignment `json:"assign"`
+	// NoStreamer lets the teams play on their own, without the streamer voting.
+	NoStreamer bool `json:"no_streamer"`
+}
+
+// TeamSummary is the score of a team over the whole game.
+type TeamSummary struct {
+	Points  int `json:"points"`
+	Won     int `json:"won"`
+	Members int `json:"members"`
+}
+
+// TeamResult is the result of a team in a single round. Vote is only set in multiple choice rounds,
+// VoteCount in rounds with answers to choose from.
+type TeamResult struct {
+	Team      string       `json:"team"`
+	Voters    int          `json:"voters"`
+	Vote      int          `json:"vote,omitempty"`
+	VoteCount []int        `json:"vote_count,omitempty"`
+	Decision  ChatDecision `json:"decision"`
+	Points    int          `json:"points"`
+	Won       bool         `json:"won"`
+}
+
+// validate normalizes the team names to lowercase and returns an error if they are not usable.
+func (m *TeamMode) validate() error {
+	if len(m.Teams) < MinTeams || len(m.Teams) > MaxTeams {
+		return fmt.Errorf("team mode needs between %d and %d teams, got %d", MinTeams, MaxTeams, len(m.Teams))
+	}
+	for i, team := range m.Teams {
+		team = strings.ToLower(strings.TrimSpace(team))
+		if team == "" || strings.ContainsAny(team, " \t") {
+			return fmt.Errorf("invalid team name '%s'", m.Teams[i])
+		}
+		if slices.Contains(m.Teams[:i], team) {
+			return fmt.Errorf("duplicate team name '%s'", team)
+		}
+		m.Teams[i] = team
+	}
+	return nil
+}
+
+// teamOf returns the team of the viewer username, or an empty string if they are in no team. With
+// [TEAMHASH] every viewer has a team, but only counts as member after their first vote. The caller
+// must hold g.mu.
+func (g *Game) teamOf(username string) string {
+	if team, ok := g.teamMembers[username]; ok {
+		return team
+	}
+	if g.TeamMode.Assign != TEAMHASH {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(username)))
+	return g.TeamMode.Teams[h.Sum32()%uint32(len(g.TeamMode.Teams))]
+}
+
+// streamerPlays reports whether the streamer votes in this game. The caller must hold g.mu.
+func (g *Game) streamerPlays() bool {
+	return g.TeamMode == nil || !g.TeamMode.NoStreamer
+}
+
+// joinTeam handles a "!join <team>" message of the viewer username. It returns the joined team and
+// reports whether msg was a valid join. Viewers can't switch teams once they joined one. The caller
+// must hold g.mu.
+func (g *Game) joinTeam(username, msg string) (team string, ok bool) {
+	if g.TeamMode == nil || g.TeamMode.Assign != TEAMJOIN {
+		return "", false
+	}
+	command, team, _ := strings.Cut(strings.ToLower(strings.TrimSpace(msg)), " ")
+	if command != "!join" {
+		return "", false
+	}
+	team = strings.TrimSpace(team)
+	if !slices.Contains(g.TeamMode.Teams, team) {
+		return "", false
+	}
+	if _, joined := g.teamMembers[username]; joined {
+		return "", false
+	}
+	g.teamMembers[username] = team
+	g.Summary.Teams[team].Members = g.teamCount(team)
+	return team, true
+}
+
+// teamCount returns the amount of members of team. The caller must hold g.mu.
+func (g *Game) teamCount(team string) (n int) {
+	for _, t := range g.teamMembers {
+		if t == team {
+			n++
+		}
+	}
+	return n
+}
+
+// scoreTeams scores the current round for each team, the same way the whole chat is scored. The
+// caller must hold g.mu.
+func (g *Game) scoreTeams(round *Round) []TeamResult {
+	results := make([]TeamResult, 0, len(g.TeamMode.Teams))
+	for _, team := range g.TeamMode.Teams {
+		result := g.scoreTeam(team, round)
+
+		sum := g.Summary.Teams[team]
+		sum.Points += result.Points
+		if result.Won {
+			sum.Won++
+		}
+		sum.Members = g.teamCount(team)
+		results = append(results, result)
+	}
+	return results
+}
+
+// scoreTeam returns the result of team in the current round. The caller must hold g.mu.
+func (g *Game) scoreTeam(team string, round *Round) TeamResult {
+	result := TeamResult{Team: team}
+	isMember := func(username string) bool {
+		return g.teamMembers[username] == team
+	}
+
+	switch round.Type {
+	case QUESTIONESTIMATE:
+		var guesses []float64
+		for username, guess := range g.chatGuesses {
+			if isMember(username) {
+				guesses = append(guesses, guess)
+			}
+		}
+		result.Voters = len(guesses)
+		if len(guesses) == 0 || round.Solution == nil {
+			break
+		}
+		err := estimateError(median(guesses), *round.Solution)
+		result.Points = estimatePoints(err, round.Tolerance)
+		result.Won = err <= round.Tolerance
+	case QUESTIONTEXT:
+		for _, username := range g.textCorrect {
+			if isMember(username) {
+				result.Voters++
+			}
+		}
+		if result.Voters > 0 {
+			result.Points = roundPoints
+			result.Won = true
+		}
+	case QUESTIONORDER, QUESTIONMATCH:
+		orders := make(map[string][]int)
+		for username, order := range g.chatOrders {
+			if isMember(username) {
+				orders[username] = order
+			}
+		}
+		result.Voters = len(orders)
+		if len(orders) == 0 {
+			break
+		}
+		result.Points, result.Won = orderPoints(chatOrder(orders, round), round)
+	default:
+		result.VoteCount = make([]int, len(round.Answers))
+		tally := make([]float64, len(round.Answers))
+		var total float64
+		for username, vote := range g.chatVotes {
+			if !isMember(username) {
+				continue
+			}
+			result.Voters++
+			total += vote.weight
+			answers := []int{vote.choice}
+			if round.Type == QUESTIONMULTI {
+				answers = selectionToList(vote.selection)
+			}
+			for _, a := range answers {
+				result.VoteCount[a-1]++
+				tally[a-1] += vote.weight
+			}
+		}
+
+		if round.Type != QUESTIONMULTI {
+			result.Vote, _, result.Points, result.Decision = g.Consensus.decideChoice(g.tieBreak(team), tally, result.Voters, round.Correct)
+			result.Won = result.Vote == round.Correct
+			break
+		}
+		if result.Voters == 0 {
+			result.Decision = DECISIONNOVOTES
+			break
+		}
+		if result.Voters < g.Consensus.MinVoters {
+			result.Decision = DECISIONMINVOTERS
+			break
+		}
+		result.Decision = DECISIONPLURALITY
+		var selection uint
+		for i, votes := range tally {
+			if votes*2 > total {
+				selection |= 1 << i
+			}
+		}
+		if selection != 0 {
+			result.Points, result.Won = selectionPoints(selection, listToSelection(round.CorrectAll))
+		}
+	}
+	return result
+}
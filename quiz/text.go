@@ -0,0 +1,121 @@
+package quiz
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// defaultTextTolerance is the maximum edit distance for free text answers if the game does not set
+// one.
+const defaultTextTolerance = 2
+
+// minFuzzyLength is the minimum length of a normalized answer for typos to be tolerated. Shorter
+// answers have to match exactly.
+const minFuzzyLength = 4
+
+// TextSummary is the result of a free text round.
+type TextSummary struct {
+	StreamerAnswer  *string `json:"streamer_answer"`
+	StreamerCorrect bool    `json:"streamer_correct"`
+	// Correct are the viewers who answered correctly, in the order they answered.
+	Correct []string `json:"correct"`
+}
+
+// normalizeText prepares s for comparison with other answers. Letters are lowercased and stripped
+// of diacritics. Whitespace and punctuation are removed completely.
+func normalizeText(s string) []rune {
+	s = strings.ReplaceAll(strings.ToLower(s), "ß", "ss")
+	var normalized []rune
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) || unicode.IsSpace(r) || unicode.IsPunct(r) {
+			continue
+		}
+		normalized = append(normalized, r)
+	}
+	return normalized
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// MatchText reports whether msg matches one of the accepted spellings. The comparison is case,
+// diacritic and whitespace insensitive and tolerates up to tolerance typos. Answers shorter than
+// [minFuzzyLength] have to match exactly.
+//
+// It is the free text equivalent of [MsgToVote].
+func MatchText(msg string, accepted []string, tolerance int) bool {
+	answer := normalizeText(msg)
+	if len(answer) == 0 {
+		return false
+	}
+	for _, spelling := range accepted {
+		expected := normalizeText(spelling)
+		if len(expected) == 0 {
+			continue
+		}
+		if string(answer) == string(expected) {
+			return true
+		}
+		if len(expected) < minFuzzyLength {
+			continue
+		}
+		if editDistance(answer, expected) <= tolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreText scores the current free text round for the streamer and chat. Chat gets the points if
+// at least one viewer answered correctly. The caller must hold g.mu.
+func (g *Game) scoreText(round *Round) {
+	sum := &TextSummary{
+		StreamerAnswer:  g.streamerText,
+		StreamerCorrect: g.streamerText != nil && MatchText(*g.streamerText, round.Accepted, g.TextTolerance),
+		Correct:         g.textCorrect,
+	}
+	g.textSummary = sum
+
+	if sum.StreamerCorrect {
+		g.Summary.StreamerPoints += roundPoints
+		g.Summary.StreamerWon++
+	}
+	if len(sum.Correct) > 0 {
+		g.Summary.ChatPoints += roundPoints
+		g.Summary.ChatWon++
+	}
+}
+
+// toTextRound converts q to a free text round. All correct answers are accepted spellings.
+func (q Question) toTextRound() Round {
+	accepted := make([]string, 0, len(q.Correct))
+	for _, c := range q.Correct {
+		accepted = append(accepted, c.Text)
+	}
+	return Round{
+		Type:     QUESTIONTEXT,
+		Question: q.Question.Text,
+		Answers:  []string{},
+		Accepted: accepted,
+	}
+}
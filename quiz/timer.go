@@ -0,0 +1,187 @@
+package quiz
+
+import (
+	"fmt"
+	"time"
+)
+
+// RoundTiming contains the timer information of the current round. All timestamps are unix
+// timestamps in milliseconds taken from the server clock. Clients should use a time sync to
+// translate them into their local time.
+type RoundTiming struct {
+	StartedAt int64 `json:"started_at"`
+	Deadline  int64 `json:"deadline,omitempty"`
+	// ChatDeadline is the deadline for chat votes. It is later than Deadline by the stream delay.
+	ChatDeadline int64 `json:"chat_deadline,omitempty"`
+	StreamDelay  int64 `json:"stream_delay_ms"`
+	Paused       bool  `json:"paused"`
+	Remaining    int64 `json:"remaining_ms"`
+	ServerTime   int64 `json:"server_time"`
+}
+
+// RoundStatus is a round together with its timing information. It is the payload sent to clients
+// whenever they get a round.
+type RoundStatus struct {
+	Round
+	RoundTiming
+}
+
+// startRoundTimer sets the deadline of the current round to d from now and starts the round timer.
+// The timer itself fires after the stream delay on top of d, so that chat votes can still arrive.
+// The caller must hold g.mu.
+func (g *Game) startRoundTimer(d time.Duration) {
+	g.stopRoundTimer()
+	g.roundDeadline = time.Now().Add(d)
+	g.RoundTimer = time.AfterFunc(d+g.streamDelay, g.endRound)
+}
+
+// streamerVoteOpen reports whether a streamer vote at t is accepted. The caller must hold g.mu.
+func (g *Game) streamerVoteOpen(t time.Time) bool {
+	return g.State == STATEQUESTION && !g.paused && !t.After(g.roundDeadline)
+}
+
+// chatVoteOpen reports whether a chat vote sent at t is accepted. Because of the stream delay chat
+// sees the question later than the streamer. Therefore votes are only accepted from the round start
+// plus the delay up to the deadline plus the delay. The caller must hold g.mu.
+func (g *Game) chatVoteOpen(t time.Time) bool {
+	if g.State != STATEQUESTION || g.paused {
+		return false
+	}
+	return !t.Before(g.roundStarted.Add(g.streamDelay)) && !t.After(g.roundDeadline.Add(g.streamDelay))
+}
+
+// stopRoundTimer stops the round timer if it is running. The caller must hold g.mu.
+func (g *Game) stopRoundTimer() {
+	if g.RoundTimer != nil {
+		g.RoundTimer.Stop()
+		g.RoundTimer = nil
+	}
+}
+
+// Remaining returns the time left in the current round. It returns 0 if no round is running.
+func (g *Game) Remaining() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.remainingTime()
+}
+
+// remainingTime is like [Game.Remaining], but the caller must hold g.mu.
+func (g *Game) remainingTime() time.Duration {
+	if g.State != STATEQUESTION {
+		return 0
+	}
+	if g.paused {
+		return g.remaining
+	}
+	return max(time.Until(g.roundDeadline), 0)
+}
+
+// roundTiming returns the timing information of the current round. The caller must hold g.mu.
+func (g *Game) roundTiming() RoundTiming {
+	timing := RoundTiming{
+		StartedAt:   g.roundStarted.UnixMilli(),
+		StreamDelay: g.streamDelay.Milliseconds(),
+		Paused:      g.paused,
+		Remaining:   g.remainingTime().Milliseconds(),
+		ServerTime:  time.Now().UnixMilli(),
+	}
+	if g.State == STATEQUESTION && !g.paused {
+		timing.Deadline = g.roundDeadline.UnixMilli()
+		timing.ChatDeadline = g.roundDeadline.Add(g.streamDelay).UnixMilli()
+	}
+	return timing
+}
+
+// sendRoundStatus sends the current round with its timing information as websocket event of the
+// given type. The caller must hold g.mu.
+func (g *Game) sendRoundStatus(eventType string) {
+	status, ok := g.currentRound()
+	if !ok {
+		return
+	}
+	g.connection.sendEvent(eventType, status)
+}
+
+// Pause pauses the timer of the current round. While paused no votes are accepted.
+func (g *Game) Pause() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkState("pause the round", STATEQUESTION); err != nil {
+		return err
+	}
+	if g.paused {
+		return fmt.Errorf("%w: round is already paused", ErrInvalidState)
+	}
+
+	g.stopRoundTimer()
+	g.stopHintTimer()
+	g.remaining = max(time.Until(g.roundDeadline), 0)
+	g.activeElapsed = g.elapsedTime()
+	g.paused = true
+	g.sendRoundStatus("ROUND_PAUSED")
+	return nil
+}
+
+// Resume continues the timer of a paused round with the time that was left when it was paused.
+func (g *Game) Resume() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkState("resume the round", STATEQUESTION); err != nil {
+		return err
+	}
+	if !g.paused {
+		return fmt.Errorf("%w: round is not paused", ErrInvalidState)
+	}
+
+	g.paused = false
+	g.activeSince = time.Now()
+	g.startRoundTimer(g.remaining)
+	g.scheduleHint()
+	g.sendRoundStatus("ROUND_RESUMED")
+	return nil
+}
+
+// AddTime extends the current round by d. If the round is paused, d is added to the remaining time
+// and takes effect when the round is resumed. d must be positive.
+func (g *Game) AddTime(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("%w: time to add must be positive, got %s", ErrInvalidArgument, d)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkState("add time to the round", STATEQUESTION); err != nil {
+		return err
+	}
+
+	if g.paused {
+		g.remaining += d
+	} else {
+		g.startRoundTimer(time.Until(g.roundDeadline) + d)
+	}
+	g.sendRoundStatus("ROUND_EXTENDED")
+	return nil
+}
+
+// EndRound ends the current round now, without waiting for the round timer. With a stream delay
+// set, only the streamer vote is closed immediately, while chat votes are still accepted for the
+// duration of the delay.
+func (g *Game) EndRound() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkState("end the round", STATEQUESTION); err != nil {
+		return err
+	}
+	if g.streamDelay <= 0 {
+		g.closeVoting()
+		return nil
+	}
+
+	if g.paused {
+		g.activeElapsed = g.elapsedTime()
+	}
+	g.paused = false
+	g.stopHintTimer()
+	g.startRoundTimer(0)
+	g.sendRoundStatus("ROUND_EXTENDED")
+	return nil
+}
@@ -1,25 +1,122 @@
 package quiz
 
 import (
+	"encoding/json"
+	"fmt"
 	logger "log"
-	"math"
 	"math/rand"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
 type Game struct {
 	connection *Connection
+	mu         sync.Mutex
+	// id identifies the game in the question history
+	id string
+	// rng is the source of all randomness in the game, seeded by seed
+	seed int64
+	rng  *rand.Rand
+	// history are the questions the streamer was asked before and the blocked ones, used when a
+	// round is replaced
+	history questionHistory
 
+	State         GameState `json:"state"`
 	Current       int
 	Rounds        []*Round
 	RoundDuration time.Duration
-	RoundTimer    *time.Timer
+	// CategoryDurations override RoundDuration for rounds of single categories, by category ID
+	CategoryDurations map[string]time.Duration
+	RoundTimer        *time.Timer
+	roundStarted      time.Time
+	// roundDeadline is the time at which the current round ends. While the round is paused it is
+	// not updated, instead remaining holds the time left when the round was paused.
+	roundDeadline time.Time
+	paused        bool
+	remaining     time.Duration
+	// streamDelay is the broadcast latency of the stream, taken from the connection at the start of
+	// each round. Chat votes are accepted that much later than the streamer vote.
+	streamDelay time.Duration
 
-	StreamerVote  int             `json:"streamer_vote"`
-	ChatVote      int             `json:"chat_vote"`
-	ChatVoteCount [4]int          `json:"chat_vote_count"`
-	voteHistory   map[string]bool `json:"-"`
+	// AutoAdvance enables the hands-free mode. The answer is revealed as soon as the voting is
+	// closed and the next round is started automatically after RevealDuration.
+	AutoAdvance    bool
+	RevealDuration time.Duration
+	advanceTimer   *time.Timer
+
+	StreamerVote  int   `json:"streamer_vote"`
+	ChatVote      int   `json:"chat_vote"`
+	ChatVoteCount []int `json:"chat_vote_count"`
 	Summary       *GameSummary
+	// roundScore are the points and won rounds of the current round, to take them back when the
+	// round is corrected
+	roundScore GameSummary
+	// chatVotes is the current vote of each viewer in this round
+	chatVotes map[string]viewerVote
+	// VoteChangePolicy defines if viewers can change their vote, MaxVoteChanges limits how often
+	// with [VOTELIMITED]
+	VoteChangePolicy VoteChangePolicy
+	MaxVoteChanges   int
+	// VoteWeights are the multipliers for chat votes by role. ChatVoteWeighted is the sum of the
+	// weights per answer, only set if any weight is set.
+	VoteWeights      VoteWeights
+	ChatVoteWeighted []float64
+	// Consensus are the rules for chat to commit to an answer. chatDecision is the rule that decided
+	// the current round, chatTied are the tied answers if the round ended in a tie. tieBreaks are
+	// the answers picked on a random tie in the current round, by team or "" for the whole chat.
+	Consensus    Consensus
+	chatDecision ChatDecision
+	chatTied     []int
+	tieBreaks    map[string]int
+	// TeamMode is set if chat plays in teams. teamMembers is the team of each viewer, teamResults
+	// the results of the teams in the current round.
+	TeamMode    *TeamMode
+	teamMembers map[string]string
+	teamResults []TeamResult
+	// Hints define when the hints of a question are revealed. hintTimes are the times at which the
+	// hints of the current round were revealed, hintTimer reveals the next one. chatHints and
+	// streamerHintsSeen are the amount of hints viewers and the streamer had seen when they voted.
+	Hints             HintSettings
+	hintTimes         []time.Time
+	hintTimer         *time.Timer
+	chatHints         map[string]int
+	streamerHintsSeen int
+	// activeElapsed is the time the current round was running before activeSince, the last time
+	// it was started or resumed
+	activeElapsed time.Duration
+	activeSince   time.Time
+	// reporters are the viewers who reported the question of the current round, in lowercase
+	reporters map[string]bool
+	// match is the match this game takes part in, if any
+	match *Match
+
+	// streamerGuess and chatGuesses hold the guesses in an estimation round
+	streamerGuess   *float64
+	chatGuesses     map[string]float64
+	estimateSummary *EstimateSummary
+
+	// AnswerCount is the amount of answers per round, unless set by the question
+	AnswerCount int
+	// Jokers is the stock of lifelines left for the streamer
+	Jokers Jokers
+
+	// TextTolerance is the maximum edit distance of free text answers
+	TextTolerance int
+	streamerText  *string
+	textCorrect   []string
+	textSummary   *TextSummary
+
+	// streamerSelection is the selection of the streamer in "select all that apply" rounds as
+	// bitmask
+	streamerSelection uint
+	multiSummary      *MultiSummary
+
+	// streamerOrder and chatOrders hold the votes in ordering and matching rounds
+	streamerOrder []int
+	chatOrders    map[string][]int
+	orderSummary  *OrderSummary
 }
 
 type GameSummary struct {
@@ -27,6 +124,14 @@ type GameSummary struct {
 	StreamerWon    int `json:"streamer_won"`
 	ChatPoints     int `json:"chat_points"`
 	ChatWon        int `json:"chat_won"`
+	// Seed reproduces the rounds of the game when passed to a new game. It is only set if the rounds
+	// depend on nothing but the seed and the questions in the sheets, so it is missing if the question
+	// history, reports or measured difficulties of the streamer changed the rounds.
+	Seed *int64 `json:"seed,omitempty"`
+
+	Teams map[string]*TeamSummary `json:"teams,omitempty"`
+	// Corrections are all corrections of rounds in this game, see [Game.CorrectRound]
+	Corrections []RoundCorrection `json:"corrections,omitempty"`
 }
 
 type CategoryGroupDefinition struct {
@@ -54,6 +159,9 @@ type CategoryDefinition struct {
 	ID    string `json:"id"`
 	Title string `json:"title"`
 	Count int    `json:"count,omitempty"`
+	// Unseen is the amount of questions the streamer was never asked. It is only set for logged in
+	// streamers.
+	Unseen *int `json:"unseen,omitempty"`
 }
 
 type Category struct {
@@ -67,9 +175,37 @@ func (c Category) GetDefinition() CategoryDefinition {
 }
 
 type Question struct {
+	Type     QuestionType         `json:"type"`
 	Question DisplayableContent   `json:"question"`
 	Correct  []DisplayableContent `json:"correct"`
 	Wrong    []DisplayableContent `json:"wrong"`
+
+	// Solution, Unit and Tolerance are only used by estimation questions. Tolerance is the relative
+	// error up to which a guess still gets points.
+	Solution  float64 `json:"solution,omitempty"`
+	Unit      string  `json:"unit,omitempty"`
+	Tolerance float64 `json:"tolerance,omitempty"`
+
+	// Items are the items of an ordering question in the correct order
+	Items []DisplayableContent `json:"items,omitempty"`
+	// Pairs are the pairs of a matching question
+	Pairs []MatchPair `json:"pairs,omitempty"`
+
+	// AnswerCount overrides the amount of answers shown for this question, if set
+	AnswerCount int `json:"answer_count,omitempty"`
+	// Difficulty is the difficulty from the sheet between [MinDifficulty] and [MaxDifficulty], or 0
+	// if not set
+	Difficulty int `json:"difficulty,omitempty"`
+	// TimeLimit overrides the duration of rounds with this question in seconds, if set
+	TimeLimit int `json:"time_limit,omitempty"`
+	// Hints are revealed one after another during the round, see [HintSettings]
+	Hints []DisplayableContent `json:"hints,omitempty"`
+	// SubmittedBy credits the viewer or channel that submitted a community question, submissionID
+	// is the ID of that [Submission]
+	SubmittedBy  string `json:"submitted_by,omitempty"`
+	submissionID string
+	// categoryID is the ID of the category the question belongs to, part of [Question.ID]
+	categoryID string
 }
 
 type DisplayableContent struct {
@@ -84,7 +220,80 @@ const (
 	CONTENTIMAGE
 )
 
+// QuestionType is the kind of question, which defines how it is answered and scored.
+type QuestionType uint8
+
+const (
+	// QUESTIONCHOICE is a question with multiple answers to choose from.
+	QUESTIONCHOICE QuestionType = iota
+	// QUESTIONESTIMATE is a question with a number as answer. Guesses are scored by how close they
+	// are to the solution.
+	QUESTIONESTIMATE
+	// QUESTIONTEXT is a question where the answer is typed into the chat. Answers are matched
+	// against a list of accepted spellings.
+	QUESTIONTEXT
+	// QUESTIONMULTI is a question with multiple correct answers, which all have to be selected.
+	QUESTIONMULTI
+	// QUESTIONORDER is a question where all answers have to be put into the correct order.
+	QUESTIONORDER
+	// QUESTIONMATCH is a question where every item on the left has to be matched to an answer.
+	QUESTIONMATCH
+)
+
+func (t QuestionType) String() string {
+	switch t {
+	case QUESTIONCHOICE:
+		return "choice"
+	case QUESTIONESTIMATE:
+		return "estimate"
+	case QUESTIONTEXT:
+		return "text"
+	case QUESTIONMULTI:
+		return "multi"
+	case QUESTIONORDER:
+		return "order"
+	case QUESTIONMATCH:
+		return "match"
+	default:
+		return fmt.Sprintf("QuestionType(%d)", t)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The type is encoded as its string representation.
+func (t QuestionType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. An empty string results in [QUESTIONCHOICE].
+func (t *QuestionType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	questionType, ok := parseQuestionType(s)
+	if !ok {
+		return fmt.Errorf("unknown question type '%s'", s)
+	}
+	*t = questionType
+	return nil
+}
+
+// parseQuestionType returns the question type with the string representation s. An empty string
+// results in [QUESTIONCHOICE].
+func parseQuestionType(s string) (QuestionType, bool) {
+	if s == "" {
+		return QUESTIONCHOICE, true
+	}
+	for t := QUESTIONCHOICE; t <= QUESTIONMATCH; t++ {
+		if t.String() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
 type Round struct {
+	Type     QuestionType            `json:"type"`
 	Question string                  `json:"question"`
 	Answers  []string                `json:"answers"`
 	Correct  int                     `json:"correct,omitempty"`
@@ -92,20 +301,76 @@ type Round struct {
 	Max      int                     `json:"max_round"`
 	Group    CategoryGroupDefinition `json:"group"`
 	Category CategoryDefinition      `json:"category"`
+	// Duration is the time limit of the round in seconds, see [Game.roundDuration]
+	Duration int `json:"duration"`
+
+	// Solution, Unit and Tolerance are only set in estimation rounds
+	Solution  *float64 `json:"solution,omitempty"`
+	Unit      string   `json:"unit,omitempty"`
+	Tolerance float64  `json:"tolerance,omitempty"`
+	// Accepted are the accepted spellings in free text rounds
+	Accepted []string `json:"accepted,omitempty"`
+	// CorrectAll are the numbers of all correct answers in "select all that apply" rounds
+	CorrectAll []int `json:"correct_all,omitempty"`
+	// Left are the items to match the answers to in matching rounds
+	Left []string `json:"left,omitempty"`
+	// CorrectOrder are the answer numbers in the correct order in ordering rounds, or the answer
+	// matching each item of Left in matching rounds
+	CorrectOrder []int `json:"correct_order,omitempty"`
+	// Removed are the answer numbers removed by the fifty-fifty joker
+	Removed []int `json:"removed,omitempty"`
+	// Difficulty is only set if the rounds are ordered by difficulty
+	Difficulty float64 `json:"difficulty,omitempty"`
+	// Hints are the hints revealed so far in this round
+	Hints []string `json:"hints,omitempty"`
+	// Voided is set if the round was voided and doesn't count
+	Voided bool `json:"voided,omitempty"`
+	// SubmittedBy credits the submitter of community questions
+	SubmittedBy string `json:"submitted_by,omitempty"`
+
+	question *Question
 }
 
 type RoundSummary struct {
 	*Round
-	StreamerPoints int    `json:"streamer_points"`
-	StreamerVote   int    `json:"streamer_vote"`
-	ChatPoints     int    `json:"chat_points"`
-	ChatVote       int    `json:"chat_vote"`
-	ChatVoteCount  [4]int `json:"chat_vote_count"`
+	StreamerPoints int   `json:"streamer_points"`
+	StreamerVote   int   `json:"streamer_vote"`
+	ChatPoints     int   `json:"chat_points"`
+	ChatVote       int   `json:"chat_vote"`
+	ChatVoteCount  []int `json:"chat_vote_count"`
+	// ChatVoteWeighted is only set if the game uses vote weights
+	ChatVoteWeighted []float64 `json:"chat_vote_weighted,omitempty"`
+	// ChatDecision is the rule that decided the vote of chat, ChatTied are the answers that had the
+	// most votes on a tie.
+	ChatDecision ChatDecision `json:"chat_decision"`
+	ChatTied     []int        `json:"chat_tied,omitempty"`
+	// StreamerHints and ChatHints are the amount of hints streamer and chat had seen when they
+	// voted, see [Game.chatHintsSeen]
+	StreamerHints int          `json:"streamer_hints,omitempty"`
+	ChatHints     int          `json:"chat_hints,omitempty"`
+	Teams         []TeamResult `json:"teams,omitempty"`
+
+	Estimate *EstimateSummary `json:"estimate,omitempty"`
+	Text     *TextSummary     `json:"text,omitempty"`
+	Multi    *MultiSummary    `json:"multi,omitempty"`
+	Order    *OrderSummary    `json:"order,omitempty"`
 }
 
 type categoryGroups map[int]CategoryGroup
 type categoryGroupDefinitions map[int]CategoryGroupDefinition
 
+// roundPoints is the amount of points for a correct answer in a round
+const roundPoints = 5
+
+const (
+	// DefaultAnswerCount is the amount of answers shown in a round, if not set otherwise.
+	DefaultAnswerCount = 4
+	// MinAnswerCount is the minimum amount of answers that can be set for a game or question.
+	MinAnswerCount = 2
+	// MaxAnswerCount is the maximum amount of answers that can be set for a game or question.
+	MaxAnswerCount = 8
+)
+
 // Categories is the main list of all Categories and Groups
 var Categories categoryGroups
 
@@ -139,7 +404,7 @@ func (cg categoryGroups) GetDefinition() (definitions map[int]CategoryGroupDefin
 	return definitions
 }
 
-func (cg categoryGroups) ShuffleCategories(groupID string, amount int, categories map[string]int) {
+func (cg categoryGroups) ShuffleCategories(rng *rand.Rand, groupID string, amount int, categories map[string]int) {
 	if amount == 0 {
 		return
 	}
@@ -158,7 +423,7 @@ func (cg categoryGroups) ShuffleCategories(groupID string, amount int, categorie
 		}
 	}
 	for range amount {
-		categoryIndex := rand.Intn(len(shuffleSelection))
+		categoryIndex := rng.Intn(len(shuffleSelection))
 		category := shuffleSelection[categoryIndex]
 
 		categories[category.ID]++
@@ -168,13 +433,31 @@ func (cg categoryGroups) ShuffleCategories(groupID string, amount int, categorie
 	}
 }
 
-func (g Game) GetRoundSummary() RoundSummary {
+// GetRoundSummary returns the summary of the current round.
+func (g *Game) GetRoundSummary() RoundSummary {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.roundSummary()
+}
+
+// roundSummary is like [Game.GetRoundSummary], but the caller must hold g.mu.
+func (g *Game) roundSummary() RoundSummary {
 	sum := RoundSummary{
-		StreamerPoints: g.Summary.StreamerPoints,
-		StreamerVote:   g.StreamerVote,
-		ChatPoints:     g.Summary.ChatPoints,
-		ChatVote:       g.ChatVote,
-		ChatVoteCount:  g.ChatVoteCount,
+		StreamerPoints:   g.Summary.StreamerPoints,
+		StreamerVote:     g.StreamerVote,
+		ChatPoints:       g.Summary.ChatPoints,
+		ChatVote:         g.ChatVote,
+		ChatVoteCount:    slices.Clone(g.ChatVoteCount),
+		ChatVoteWeighted: slices.Clone(g.ChatVoteWeighted),
+		ChatDecision:     g.chatDecision,
+		ChatTied:         g.chatTied,
+		Teams:            g.teamResults,
+		StreamerHints:    g.streamerHintsSeen,
+		ChatHints:        g.chatHintsSeen(),
+		Estimate:         g.estimateSummary,
+		Text:             g.textSummary,
+		Multi:            g.multiSummary,
+		Order:            g.orderSummary,
 	}
 	if g.Current > 0 {
 		sum.Round = g.Rounds[g.Current-1]
@@ -182,126 +465,561 @@ func (g Game) GetRoundSummary() RoundSummary {
 	return sum
 }
 
+// censor removes the correct answer from r.
+func (r *Round) censor() {
+	r.Correct = 0
+	r.Solution = nil
+	r.Accepted = nil
+	r.CorrectAll = nil
+	r.CorrectOrder = nil
+}
+
+// GetState returns the current state of the game.
+func (g *Game) GetState() GameState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.State
+}
+
+// CurrentRound returns a copy of the current round with its timing information. The correct answer
+// is censored unless the round is already revealed. If there is no current round, ok is false.
+func (g *Game) CurrentRound() (status RoundStatus, ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.currentRound()
+}
+
+// currentRound is like [Game.CurrentRound], but the caller must hold g.mu.
+func (g *Game) currentRound() (status RoundStatus, ok bool) {
+	if g.Current == 0 || g.Current > len(g.Rounds) {
+		return RoundStatus{}, false
+	}
+	status.Round = *g.Rounds[g.Current-1]
+	if g.State != STATEREVEAL && g.State != STATEFINISHED {
+		status.Round.censor()
+	}
+	status.RoundTiming = g.roundTiming()
+	return status, true
+}
+
 // NextRound advances the game to the next round. That includes incrementing the counter and setting
-// a new round timer.
-func (g *Game) NextRound() {
+// a new round timer. If the last round was already revealed, NextRound finishes the game instead.
+//
+// NextRound is only allowed in the lobby or after the current round was revealed.
+func (g *Game) NextRound() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.isGuest() {
+		return fmt.Errorf("%w: the rounds of match %s are started by the host", ErrInvalidState, g.match.Code)
+	}
+	return g.nextRound()
+}
+
+// nextRound is like [Game.NextRound], but the caller must hold g.mu.
+func (g *Game) nextRound() error {
+	if err := g.checkState("start next round", STATELOBBY, STATEREVEAL); err != nil {
+		return err
+	}
+	g.stopAdvanceTimer()
+	if g.State == STATEREVEAL {
+		// the result is saved only when the round is left, so it can still be voided or corrected
+		g.recordResult()
+	}
+
+	if g.isHost() {
+		defer func() { go g.match.follow() }()
+	}
+
+	if g.Current >= len(g.Rounds) {
+		g.State = STATEFINISHED
+		g.connection.sendEvent("GAME_END", g.Summary)
+		return nil
+	}
+
 	g.Current++
+	g.round().Duration = int(g.roundDuration(g.round()).Seconds())
+	g.startRound()
+	return nil
+}
+
+// roundDuration returns the time limit of round. The limit of the question takes precedence over the
+// one of the category, which takes precedence over the one of the game.
+func (g *Game) roundDuration(round *Round) time.Duration {
+	if round.question != nil && round.question.TimeLimit > 0 {
+		return time.Duration(round.question.TimeLimit) * time.Second
+	}
+	if d, ok := g.CategoryDurations[round.Category.ID]; ok {
+		return d
+	}
+	return g.RoundDuration
+}
+
+// startRound resets all votes and starts the timer for the current round. The caller must hold g.mu.
+func (g *Game) startRound() {
 	g.StreamerVote = 0
-	g.voteHistory = make(map[string]bool)
-	g.ChatVoteCount = [4]int{}
-	g.RoundTimer = time.AfterFunc(g.RoundDuration, g.endRound)
+	g.ChatVote = 0
+	g.chatVotes = make(map[string]viewerVote)
+	g.ChatVoteCount = make([]int, len(g.Rounds[g.Current-1].Answers))
+	g.chatDecision = DECISIONNONE
+	g.chatTied = nil
+	g.tieBreaks = make(map[string]int)
+	g.teamResults = nil
+	g.roundScore = GameSummary{}
+	g.ChatVoteWeighted = nil
+	if !g.VoteWeights.IsZero() {
+		g.ChatVoteWeighted = make([]float64, len(g.ChatVoteCount))
+	}
+	g.streamerGuess = nil
+	g.chatGuesses = make(map[string]float64)
+	g.estimateSummary = nil
+	g.streamerText = nil
+	g.textCorrect = nil
+	g.textSummary = nil
+	g.streamerSelection = 0
+	g.multiSummary = nil
+	g.streamerOrder = nil
+	g.chatOrders = make(map[string][]int)
+	g.orderSummary = nil
+	g.hintTimes = nil
+	g.chatHints = make(map[string]int)
+	g.streamerHintsSeen = 0
+	g.reporters = make(map[string]bool)
+	g.round().Hints = nil
+	g.State = STATEQUESTION
+	g.paused = false
+	g.streamDelay = g.connection.StreamDelay
+	g.roundStarted = time.Now()
+	g.activeElapsed = 0
+	g.activeSince = g.roundStarted
+	g.startRoundTimer(time.Duration(g.round().Duration) * time.Second)
+	g.scheduleHint()
+	g.recordQuestion()
+	g.sendRoundStatus("ROUND_START")
+}
+
+// SetStreamerVote sets the vote of the streamer for the current round. msg is parsed depending on
+// the type of the round, e.g. with [MsgToVote] for multiple choice rounds.
+func (g *Game) SetStreamerVote(msg string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkState("vote", STATEQUESTION); err != nil {
+		return err
+	}
+	if g.paused {
+		return fmt.Errorf("%w: cannot vote while the round is paused", ErrInvalidState)
+	}
+	if !g.streamerVoteOpen(time.Now()) {
+		return fmt.Errorf("%w: the time to vote is over", ErrInvalidState)
+	}
+	return g.streamerVote(msg, false)
 }
 
+// round returns the current round or nil if there is none. The caller must hold g.mu.
+func (g *Game) round() *Round {
+	if g.Current == 0 || g.Current > len(g.Rounds) {
+		return nil
+	}
+	return g.Rounds[g.Current-1]
+}
+
+// streamerVote parses msg as vote of the streamer for the current round. In free text rounds any
+// msg is accepted as answer, unless fromChat is set. Then only matching answers count, because the
+// streamer might just be chatting. The caller must hold g.mu and check whether voting is open.
+func (g *Game) streamerVote(msg string, fromChat bool) error {
+	round := g.round()
+	if round == nil {
+		return fmt.Errorf("%w: no active round", ErrInvalidState)
+	}
+	if !g.streamerPlays() {
+		return fmt.Errorf("%w: the streamer does not play in this game", ErrInvalidState)
+	}
+
+	switch round.Type {
+	case QUESTIONESTIMATE:
+		if g.streamerGuess != nil {
+			return ErrAlreadyVoted
+		}
+		guess, ok := round.parseGuess(msg)
+		if !ok {
+			return fmt.Errorf("%w: '%s' is not a number", ErrInvalidVote, msg)
+		}
+		g.streamerGuess = &guess
+	case QUESTIONTEXT:
+		if g.streamerText != nil {
+			return ErrAlreadyVoted
+		}
+		if strings.TrimSpace(msg) == "" || fromChat && !MatchText(msg, round.Accepted, g.TextTolerance) {
+			return fmt.Errorf("%w: '%s' is not an answer", ErrInvalidVote, msg)
+		}
+		g.streamerText = &msg
+	case QUESTIONMULTI:
+		if g.streamerSelection != 0 {
+			return ErrAlreadyVoted
+		}
+		selection := MsgToSelection(msg, g)
+		if selection == 0 {
+			return fmt.Errorf("%w: '%s' is not a valid selection", ErrInvalidVote, msg)
+		}
+		g.streamerSelection = selection
+	case QUESTIONORDER, QUESTIONMATCH:
+		if g.streamerOrder != nil {
+			return ErrAlreadyVoted
+		}
+		order := MsgToPermutation(msg, g)
+		if order == nil {
+			return fmt.Errorf("%w: '%s' is not a valid order of all answers", ErrInvalidVote, msg)
+		}
+		g.streamerOrder = order
+	default:
+		if g.StreamerVote != 0 {
+			return ErrAlreadyVoted
+		}
+		vote := MsgToVote(msg, g)
+		if vote == 0 {
+			return fmt.Errorf("%w: '%s' is not a valid vote option", ErrInvalidVote, msg)
+		}
+		g.StreamerVote = vote
+	}
+	g.streamerHintsSeen = g.hintsSeen(time.Now(), 0)
+	return nil
+}
+
+// chatVote parses msg as vote of the viewer username for the current round. weight is the
+// multiplier of the vote from [VoteWeights.Weight]. It reports whether msg was a valid vote and was
+// counted, and whether it replaced a previous vote of the viewer according to the vote change
+// policy. In team mode only votes of viewers in a team are counted. The caller must hold g.mu and
+// check whether voting is open.
+func (g *Game) chatVote(username, msg string, weight float64) (ok, changed bool) {
+	round := g.round()
+	if round == nil {
+		return false, false
+	}
+	var team string
+	if g.TeamMode != nil {
+		if team = g.teamOf(username); team == "" {
+			return false, false
+		}
+	}
+	prev, voted := g.chatVotes[username]
+	if voted && (round.Type == QUESTIONTEXT || !g.VoteChangePolicy.allowsChange(prev.changes, g.MaxVoteChanges)) {
+		// ignore users who already voted
+		return false, false
+	}
+	vote := viewerVote{weight: weight}
+	if voted {
+		vote.changes = prev.changes + 1
+	}
+
+	switch round.Type {
+	case QUESTIONESTIMATE:
+		guess, ok := round.parseGuess(msg)
+		if !ok || voted && g.chatGuesses[username] == guess {
+			return false, false
+		}
+		g.chatGuesses[username] = guess
+	case QUESTIONTEXT:
+		if !MatchText(msg, round.Accepted, g.TextTolerance) {
+			// wrong answers can't be told apart from regular chat messages
+			return false, false
+		}
+		g.textCorrect = append(g.textCorrect, username)
+	case QUESTIONMULTI:
+		selection := MsgToSelection(msg, g)
+		if selection == 0 || voted && prev.selection == selection {
+			return false, false
+		}
+		for _, v := range selectionToList(prev.selection) {
+			g.countVote(v, -1, prev.weight)
+		}
+		for _, v := range selectionToList(selection) {
+			g.countVote(v, 1, weight)
+		}
+		vote.selection = selection
+	case QUESTIONORDER, QUESTIONMATCH:
+		order := MsgToPermutation(msg, g)
+		if order == nil || voted && slices.Equal(g.chatOrders[username], order) {
+			return false, false
+		}
+		g.chatOrders[username] = order
+	default:
+		choice := MsgToVote(msg, g)
+		if choice == 0 || voted && prev.choice == choice {
+			// ignoring non-valid votes
+			return false, false
+		}
+		if voted {
+			g.countVote(prev.choice, -1, prev.weight)
+		}
+		g.countVote(choice, 1, weight)
+		vote.choice = choice
+	}
+	g.chatVotes[username] = vote
+	g.chatHints[username] = g.hintsSeen(time.Now(), g.streamDelay)
+	if team != "" {
+		g.teamMembers[username] = team
+	}
+	return true, voted
+}
+
+// countVote adds n votes with the given weight to answer (indexed 1). The caller must hold g.mu.
+func (g *Game) countVote(answer, n int, weight float64) {
+	g.ChatVoteCount[answer-1] += n
+	if g.ChatVoteWeighted != nil {
+		g.ChatVoteWeighted[answer-1] += float64(n) * weight
+	}
+}
+
+// Reveal reveals the correct answer of the current round by sending the round summary. It is only
+// allowed after the voting of the round was closed.
+func (g *Game) Reveal() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.reveal()
+}
+
+// reveal is like [Game.Reveal], but the caller must hold g.mu. In auto-advance mode it schedules
+// the next round after the reveal duration.
+func (g *Game) reveal() error {
+	if err := g.checkState("reveal the answer", STATEVOTINGCLOSED); err != nil {
+		return err
+	}
+
+	g.State = STATEREVEAL
+	g.connection.sendEvent("ROUND_END", g.roundSummary())
+	if g.match != nil {
+		g.match.report(g)
+	}
+
+	if g.AutoAdvance {
+		g.stopAdvanceTimer()
+		g.advanceTimer = time.AfterFunc(g.RevealDuration, g.autoAdvance)
+	}
+	return nil
+}
+
+// autoAdvance is called by the advance timer after the reveal duration to start the next round or
+// to finish the game.
+func (g *Game) autoAdvance() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.State != STATEREVEAL {
+		// the game was advanced manually in the meantime
+		return
+	}
+	err := g.nextRound()
+	if err != nil {
+		log.Printf("Error advancing game automatically: %v", err)
+	}
+}
+
+// stopAdvanceTimer stops the auto-advance timer if it is running. The caller must hold g.mu.
+func (g *Game) stopAdvanceTimer() {
+	if g.advanceTimer != nil {
+		g.advanceTimer.Stop()
+		g.advanceTimer = nil
+	}
+}
+
+// Stop stops all running timers of the game. It should be called when the game is discarded.
+func (g *Game) Stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopRoundTimer()
+	g.stopHintTimer()
+	g.stopAdvanceTimer()
+}
+
+// endRound is called by the round timer when the time for the current round ran out.
 func (g *Game) endRound() {
 	if g == nil || g.connection == nil {
 		return
 	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused || time.Now().Before(g.roundDeadline.Add(g.streamDelay)) {
+		// The timer was stopped or reset while this call was already waiting for the lock.
+		return
+	}
+	g.closeVoting()
+}
 
-	if g.RoundTimer != nil && g.RoundTimer.Stop() {
-		// If the round timer was running, the call to Stop will run this function again. To prevent
-		// duplicates we exit here.
+// closeVoting stops accepting votes for the current round and calculates the points. The caller
+// must hold g.mu.
+func (g *Game) closeVoting() {
+	if g.State != STATEQUESTION {
 		return
 	}
-	g.RoundTimer = nil
+	g.stopRoundTimer()
+	g.stopHintTimer()
+	g.paused = false
+
+	g.score(g.round())
+
+	g.State = STATEVOTINGCLOSED
+	g.connection.sendEvent("VOTING_CLOSED", struct {
+		Current int `json:"current_round"`
+	}{
+		Current: g.Current,
+	})
 
-	// determine winner
-	correct := g.Rounds[g.Current-1].Correct
-	const roundPoints = 5
+	if g.AutoAdvance {
+		g.reveal()
+	}
+}
 
+// score calculates the points of the current round and adds them to the summary. The caller must
+// hold g.mu.
+func (g *Game) score(round *Round) {
+	before := g.Summary.clone()
+	switch round.Type {
+	case QUESTIONESTIMATE:
+		g.scoreEstimate(round)
+	case QUESTIONTEXT:
+		g.scoreText(round)
+	case QUESTIONMULTI:
+		g.scoreMulti(round)
+	case QUESTIONORDER, QUESTIONMATCH:
+		g.scoreOrder(round)
+	default:
+		g.scoreChoice(round)
+	}
+	g.applyHintPenalty(before.StreamerPoints, before.ChatPoints)
+	if g.TeamMode != nil {
+		g.teamResults = g.scoreTeams(round)
+	}
+	g.roundScore = g.Summary.since(before)
+}
+
+// scoreChoice determines the winners of the current multiple choice round. The answer of chat is
+// decided by the [Consensus] rules of g. The caller must hold g.mu.
+func (g *Game) scoreChoice(round *Round) {
+	correct := round.Correct
 	if g.StreamerVote == correct {
 		g.Summary.StreamerPoints += roundPoints
 		g.Summary.StreamerWon++
 	}
-	chatCorrect := g.ChatVoteCount[correct-1]
-	g.ChatVote = correct
-	var totalVotes int
-	for i, votes := range g.ChatVoteCount {
-		totalVotes += votes
-		if votes > chatCorrect {
-			g.ChatVote = i + 1
-		}
-	}
-	if totalVotes > 0 && g.ChatVote == correct {
-		g.Summary.ChatPoints += roundPoints
+	answer, tied, points, decision := g.Consensus.decideChoice(g.tieBreak(""), g.chatTally(), len(g.chatVotes), correct)
+	g.ChatVote = answer
+	g.chatTied = tied
+	g.chatDecision = decision
+	g.Summary.ChatPoints += points
+	if answer == correct {
 		g.Summary.ChatWon++
-	} else if totalVotes == 0 {
-		g.ChatVote = 0
-	}
-
-	// send to ws
-	if g.connection.WS == nil {
-		log.Println("tried to end round, but no websocket is not connected")
-		return
 	}
+}
 
-	roundSummary := struct {
-		Type string `json:"type"`
-		RoundSummary
-	}{
-		Type:         "ROUND_END",
-		RoundSummary: g.GetRoundSummary(),
+// tieBreak returns a function that picks one of the tied answers of the current round for team,
+// or for the whole chat if team is empty. The answer is picked by g.rng the first time and kept
+// for the round, so scoring it again after a correction keeps the same pick. The caller must hold
+// g.mu.
+func (g *Game) tieBreak(team string) func(tied []int) int {
+	return func(tied []int) int {
+		if answer, ok := g.tieBreaks[team]; ok && slices.Contains(tied, answer) {
+			return answer
+		}
+		answer := tied[g.rng.Intn(len(tied))]
+		g.tieBreaks[team] = answer
+		return answer
 	}
-	g.connection.WS.WriteJSON(roundSummary)
 }
 
 // GetRounds tries to get n questions from c. If c contains less than n questions, GetRounds returns
-// all questions of c.
+// all questions of c. answers is the amount of answers per round, see [Question.ToRound].
 //
-// The returned questions are in a randomized order.
-func (c Category) GetRounds(n int) []*Round {
+// Questions in history are skipped if they are in cooldown and only used after all unseen questions
+// otherwise. The returned questions are in an order randomized by rng.
+func (c Category) GetRounds(rng *rand.Rand, n, answers int, history questionHistory) []*Round {
 	if n == 0 {
 		return []*Round{}
 	}
 
-	rand.Shuffle(len(c.Pool), func(i, j int) {
-		c.Pool[i], c.Pool[j] = c.Pool[j], c.Pool[i]
+	// shuffle a copy, so the result does not depend on previous games
+	pool := slices.Clone(c.Pool)
+	rng.Shuffle(len(pool), func(i, j int) {
+		pool[i], pool[j] = pool[j], pool[i]
 	})
 
-	var questions []*Question
-	if n >= len(c.Pool) {
-		questions = c.Pool
-	} else {
-		questions = c.Pool[:n]
+	var unseen, seen []*Question
+	for _, q := range pool {
+		switch {
+		case q == nil || history.blocked[q.ID()]:
+		case history.seen[q.ID()]:
+			seen = append(seen, q)
+		default:
+			unseen = append(unseen, q)
+		}
+	}
+	questions := append(unseen, seen...)
+	if n < len(questions) {
+		questions = questions[:n]
 	}
 
 	var rounds = make([]*Round, 0, len(questions))
 	for _, q := range questions {
-		if q == nil {
-			continue
-		}
-		round := q.ToRound()
+		round := q.ToRound(rng, answers)
+		round.question = q
 		round.Category = c.GetDefinition()
 		rounds = append(rounds, &round)
 	}
 	return rounds
 }
 
-func (q Question) ToRound() Round {
+// ToRound converts q to a round with up to answers answers. If q sets its own answer count, that
+// one is used instead. The selection and order of the answers is randomized by rng.
+func (q Question) ToRound(rng *rand.Rand, answers int) Round {
+	if q.AnswerCount > 0 {
+		answers = q.AnswerCount
+	}
+
+	var round Round
+	switch q.Type {
+	case QUESTIONESTIMATE:
+		round = q.toEstimateRound()
+	case QUESTIONTEXT:
+		round = q.toTextRound()
+	case QUESTIONMULTI:
+		round = q.toMultiRound(rng, answers)
+	case QUESTIONORDER:
+		round = q.toOrderRound(rng, answers)
+	case QUESTIONMATCH:
+		round = q.toMatchRound(rng, answers)
+	default:
+		round = q.toChoiceRound(rng, answers)
+	}
+	round.SubmittedBy = q.SubmittedBy
+	return round
+}
+
+// toChoiceRound converts q to a multiple choice round with one correct answer and up to n-1 wrong
+// answers.
+func (q Question) toChoiceRound(rng *rand.Rand, n int) Round {
 	var answers []string
 
 	// select one correct answer
 	if len(q.Correct) > 1 {
-		rand.Shuffle(len(q.Correct), func(i, j int) {
-			q.Correct[i], q.Correct[j] = q.Correct[j], q.Correct[i]
+		correctAnswers := slices.Clone(q.Correct)
+		rng.Shuffle(len(correctAnswers), func(i, j int) {
+			correctAnswers[i], correctAnswers[j] = correctAnswers[j], correctAnswers[i]
 		})
-		answers = append(answers, q.Correct[rand.Intn(len(q.Correct)-1)].Text)
+		answers = append(answers, correctAnswers[rng.Intn(len(correctAnswers)-1)].Text)
 	} else {
 		answers = append(answers, q.Correct[0].Text)
 	}
 
-	// select up to 3 wrong answers
-	if len(q.Wrong) > 3 {
-		rand.Shuffle(len(q.Wrong), func(i, j int) {
-			q.Wrong[i], q.Wrong[j] = q.Wrong[j], q.Wrong[i]
+	// select up to n-1 wrong answers
+	wrong := slices.Clone(q.Wrong)
+	if len(wrong) > n-1 {
+		rng.Shuffle(len(wrong), func(i, j int) {
+			wrong[i], wrong[j] = wrong[j], wrong[i]
 		})
 	}
-	num_wrong := int(math.Min(float64(cap(q.Wrong)), 3))
-	for _, a := range q.Wrong[:num_wrong] {
+	num_wrong := min(len(wrong), n-1)
+	for _, a := range wrong[:num_wrong] {
 		answers = append(answers, a.Text)
 	}
 
 	var correct int
-	rand.Shuffle(len(answers), func(i, j int) {
+	rng.Shuffle(len(answers), func(i, j int) {
 		answers[i], answers[j] = answers[j], answers[i]
 		if i == correct || j == correct {
 			correct = i + j - correct
@@ -0,0 +1,79 @@
+package quiz
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// VoteChangePolicy defines whether viewers can change their vote in a round.
+type VoteChangePolicy uint8
+
+const (
+	// VOTEFIRST only counts the first vote of each viewer. This is the default.
+	VOTEFIRST VoteChangePolicy = iota
+	// VOTELAST counts the last vote of each viewer, so they can change it as often as they like.
+	VOTELAST
+	// VOTELIMITED allows each viewer to change their vote up to [Game.MaxVoteChanges] times.
+	VOTELIMITED
+)
+
+// viewerVote is the current vote of a single viewer in a round. Depending on the type of the round
+// only one of choice or selection is set. Guesses and orders are kept in their own maps.
+type viewerVote struct {
+	choice    int
+	selection uint
+	// changes is the amount of times the viewer changed their vote
+	changes int
+	// weight is the multiplier of the vote by the role of the viewer
+	weight float64
+}
+
+func (p VoteChangePolicy) String() string {
+	switch p {
+	case VOTEFIRST:
+		return "first"
+	case VOTELAST:
+		return "last"
+	case VOTELIMITED:
+		return "limited"
+	default:
+		return fmt.Sprintf("VoteChangePolicy(%d)", p)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]. The policy is encoded as its string representation.
+func (p VoteChangePolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. An empty string results in [VOTEFIRST].
+func (p *VoteChangePolicy) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	switch s {
+	case "", "first":
+		*p = VOTEFIRST
+	case "last":
+		*p = VOTELAST
+	case "limited":
+		*p = VOTELIMITED
+	default:
+		return fmt.Errorf("unknown vote change policy '%s'", s)
+	}
+	return nil
+}
+
+// allowsChange reports whether a viewer who already changed their vote changes times can change it
+// again.
+func (p VoteChangePolicy) allowsChange(changes, maxChanges int) bool {
+	switch p {
+	case VOTELAST:
+		return true
+	case VOTELIMITED:
+		return changes < maxChanges
+	default:
+		return false
+	}
+}
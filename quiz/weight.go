@@ -0,0 +1,116 @@
+package quiz
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kesuaheli/twitchgo"
+)
+
+// VoteWeights is a table of multipliers for chat votes by the Twitch role of the viewer. A viewer
+// with several roles gets the highest multiplier that is set for them, which can also be below 1.
+// Viewers without a role with a multiplier count as 1.
+//
+// Weights only apply to rounds that are decided by counting votes, i.e. multiple choice and
+// "select all that apply" rounds.
+type VoteWeights struct {
+	Subscriber      float64 `json:"subscriber,omitempty"`
+	SubscriberTier2 float64 `json:"subscriber_tier2,omitempty"`
+	SubscriberTier3 float64 `json:"subscriber_tier3,omitempty"`
+	VIP             float64 `json:"vip,omitempty"`
+	Moderator       float64 `json:"moderator,omitempty"`
+}
+
+// IsZero reports whether no weight is set at all.
+func (w VoteWeights) IsZero() bool {
+	return w == VoteWeights{}
+}
+
+// validate returns an error if one of the multipliers is negative.
+func (w VoteWeights) validate() error {
+	for name, value := range map[string]float64{
+		"subscriber":       w.Subscriber,
+		"subscriber_tier2": w.SubscriberTier2,
+		"subscriber_tier3": w.SubscriberTier3,
+		"vip":              w.VIP,
+		"moderator":        w.Moderator,
+	} {
+		if value < 0 {
+			return fmt.Errorf("vote weight '%s' must not be negative, got %g", name, value)
+		}
+	}
+	return nil
+}
+
+// Weight returns the multiplier for a vote of the viewer with the given tags.
+func (w VoteWeights) Weight(tags twitchgo.IRCMessageTags) float64 {
+	var weight float64
+	var found bool
+	use := func(multiplier float64) {
+		if multiplier != 0 && (!found || multiplier > weight) {
+			weight = multiplier
+			found = true
+		}
+	}
+
+	if version, ok := badgeVersion(tags, "subscriber"); ok || tags.Subscriber {
+		use(w.Subscriber)
+		// The version of the subscriber badge is the amount of months, plus 2000 or 3000 for tier 2
+		// or tier 3 subscriptions.
+		tier, _ := strconv.Atoi(version)
+		if tier >= 2000 {
+			use(w.SubscriberTier2)
+		}
+		if tier >= 3000 {
+			use(w.SubscriberTier3)
+		}
+	}
+	if _, ok := badgeVersion(tags, "vip"); ok || tags.VIP {
+		use(w.VIP)
+	}
+	if _, ok := badgeVersion(tags, "moderator"); ok || tags.Mod {
+		use(w.Moderator)
+	}
+	if !found {
+		return 1
+	}
+	return weight
+}
+
+// badgeVersion returns the version of the badge with the given name, if the viewer has it. Badges
+// are in the form "<badge>/<version>".
+func badgeVersion(tags twitchgo.IRCMessageTags, name string) (version string, ok bool) {
+	for _, badge := range tags.Badges {
+		badgeName, version, _ := strings.Cut(badge, "/")
+		if badgeName == name {
+			return version, true
+		}
+	}
+	return "", false
+}
+
+// chatTally returns the votes per answer that decide the chat vote. That are the weighted votes if
+// vote weights are set, or the plain vote count otherwise. The caller must hold g.mu.
+func (g *Game) chatTally() []float64 {
+	if !g.VoteWeights.IsZero() {
+		return g.ChatVoteWeighted
+	}
+	tally := make([]float64, len(g.ChatVoteCount))
+	for i, votes := range g.ChatVoteCount {
+		tally[i] = float64(votes)
+	}
+	return tally
+}
+
+// chatTallyTotal returns the total weight of all viewers who voted. The caller must hold g.mu.
+func (g *Game) chatTallyTotal() float64 {
+	if g.VoteWeights.IsZero() {
+		return float64(len(g.chatVotes))
+	}
+	var total float64
+	for _, vote := range g.chatVotes {
+		total += vote.weight
+	}
+	return total
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"quiz_backend/database"
 	"quiz_backend/quiz"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kesuaheli/twitchgo"
@@ -178,7 +180,17 @@ func handleChat(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleCategory(w http.ResponseWriter, r *http.Request) {
-	b, err := json.Marshal(quiz.Categories.GetDefinition())
+	var definitions any = quiz.Categories.GetDefinition()
+	if c, ok := isAuthorized(r); ok {
+		withUnseen, err := c.CategoryDefinitions()
+		if err != nil {
+			log.Printf("Failed to get unseen questions: %v", err)
+		} else {
+			definitions = withUnseen
+		}
+	}
+
+	b, err := json.Marshal(definitions)
 	if err != nil {
 		log.Printf("Failed to marshal categories: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -188,6 +200,22 @@ func handleCategory(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+func resetHistory(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err := c.ResetQuestionHistory()
+	if err != nil {
+		log.Printf("Failed to reset question history: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func handleGame(w http.ResponseWriter, r *http.Request) {
 	c, ok := isAuthorized(r)
 	if !ok {
@@ -223,6 +251,10 @@ func handleGame(w http.ResponseWriter, r *http.Request) {
 		w.Write(b)
 		return
 	case http.MethodDelete:
+		c.LeaveMatch()
+		if c.Game != nil {
+			c.Game.Stop()
+		}
 		c.Game = nil
 		return
 	default:
@@ -243,11 +275,6 @@ func handleStreamerVote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if c.Game.StreamerVote != 0 {
-		http.Error(w, "Streamer did already leave a vote!", http.StatusPreconditionFailed)
-		return
-	}
-
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("Failed to read request body: %v", err)
@@ -264,12 +291,14 @@ func handleStreamerVote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c.Game.StreamerVote = quiz.MsgToVote(streamerVoteData.Vote, c.Game)
-	if c.Game.StreamerVote == 0 {
-		http.Error(w, streamerVoteData.Vote+" is not a valid vote option", http.StatusBadRequest)
+	err = c.Game.SetStreamerVote(streamerVoteData.Vote)
+	if errors.Is(err, quiz.ErrAlreadyVoted) {
+		http.Error(w, "Streamer did already leave a vote!", http.StatusPreconditionFailed)
+		return
+	} else if err != nil {
+		writeGameError(w, err)
 		return
 	}
-
 }
 
 func getRound(w http.ResponseWriter, r *http.Request) {
@@ -284,13 +313,12 @@ func getRound(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if c.Game.Current == 0 {
+	round, ok := c.Game.CurrentRound()
+	if !ok {
 		http.Error(w, "no active round", http.StatusNotFound)
 		return
 	}
 
-	round := *c.Game.Rounds[c.Game.Current-1]
-	round.Correct = 0 // censoring correct answer
 	b, err := json.Marshal(round)
 	if err != nil {
 		log.Printf("Failed to marshal current round: %v", err)
@@ -313,8 +341,14 @@ func nextRound(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if c.Game.Current >= len(c.Game.Rounds) {
-		// if this is the last round send game summary
+	err := c.Game.NextRound()
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	if c.Game.GetState() == quiz.STATEFINISHED {
+		// if this was the last round send game summary
 		b, err := json.Marshal(c.Game.Summary)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -325,10 +359,7 @@ func nextRound(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c.Game.NextRound()
-
-	round := *c.Game.Rounds[c.Game.Current-1]
-	round.Correct = 0 // censoring correct answer
+	round, _ := c.Game.CurrentRound()
 	b, err := json.Marshal(round)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -336,3 +367,240 @@ func nextRound(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write(b)
 }
+
+func revealRound(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if c.Game == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := c.Game.Reveal()
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(c.Game.GetRoundSummary())
+	if err != nil {
+		log.Printf("Failed to marshal round summary: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
+
+// writeGameError writes an error returned by an action on a game to w. Actions that are not allowed
+// in the current state of the game result in a 409 Conflict.
+func writeGameError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, quiz.ErrInvalidState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, quiz.ErrInvalidVote), errors.Is(err, quiz.ErrInvalidArgument):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, quiz.ErrNoJokerLeft):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, quiz.ErrMatchNotFound), errors.Is(err, quiz.ErrPresetNotFound),
+		errors.Is(err, quiz.ErrReportNotFound), errors.Is(err, quiz.ErrSubmissionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		log.Printf("Game error: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// handleRoundAction returns a handler for simple actions on the current round of the game, that
+// don't need a request body.
+func handleRoundAction(action func(g *quiz.Game) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := isAuthorized(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if c.Game == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err := action(c.Game)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+	}
+}
+
+// handleJoker returns a handler for using one of the streamer jokers in the current round.
+func handleJoker(use func(g *quiz.Game) (quiz.JokerResult, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := isAuthorized(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if c.Game == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		result, err := use(c.Game)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+
+		b, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("Failed to marshal joker result: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(b)
+	}
+}
+
+func extendRound(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if c.Game == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Failed to read request body: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var extendData struct {
+		Seconds int `json:"seconds"`
+	}
+	err = json.Unmarshal(body, &extendData)
+	if err != nil {
+		http.Error(w, "Not a valid json body. Need key 'seconds'", http.StatusBadRequest)
+		return
+	}
+
+	err = c.Game.AddTime(time.Duration(extendData.Seconds) * time.Second)
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+}
+
+// handleCorrection returns a handler for correcting the current round of the game with the given
+// action. The body needs a "reason" and for [quiz.CORRECTIONANSWER] the new correct "answer". The
+// optional "round" is checked against the current round, as earlier rounds can't be corrected.
+func handleCorrection(action quiz.CorrectionAction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := isAuthorized(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if c.Game == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("Failed to read request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var correctionData struct {
+			Round  int    `json:"round"`
+			Reason string `json:"reason"`
+			Answer string `json:"answer"`
+		}
+		err = json.Unmarshal(body, &correctionData)
+		if err != nil {
+			http.Error(w, "Not a valid json body. Need key 'reason'", http.StatusBadRequest)
+			return
+		}
+
+		correction, err := c.Game.CorrectRound(correctionData.Round, action, correctionData.Reason, correctionData.Answer)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+
+		b, err := json.Marshal(correction)
+		if err != nil {
+			log.Printf("Failed to marshal round correction: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(b)
+	}
+}
+
+func handleSettings(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	type settings struct {
+		// StreamDelay is the broadcast latency in seconds
+		StreamDelay float64 `json:"stream_delay"`
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("Failed to read request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var newSettings settings
+		err = json.Unmarshal(body, &newSettings)
+		if err != nil {
+			http.Error(w, "Not a valid json body", http.StatusBadRequest)
+			return
+		}
+		if newSettings.StreamDelay < 0 || newSettings.StreamDelay > 60 {
+			http.Error(w, "stream_delay must be between 0 and 60 seconds", http.StatusBadRequest)
+			return
+		}
+		err = c.SetStreamDelay(time.Duration(newSettings.StreamDelay * float64(time.Second)))
+		if err != nil {
+			log.Printf("Failed to save settings: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	b, err := json.Marshal(settings{
+		StreamDelay: c.StreamDelay.Seconds(),
+	})
+	if err != nil {
+		log.Printf("Failed to marshal settings: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
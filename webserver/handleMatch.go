@@ -0,0 +1,84 @@
+package webserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"quiz_backend/quiz"
+)
+
+// handleMatch creates (POST), gets the scoreboard of (GET) or leaves (DELETE) the match of the
+// current game.
+func handleMatch(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		_, err := c.CreateMatch()
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+	case http.MethodDelete:
+		c.LeaveMatch()
+		return
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeMatch(w, c)
+}
+
+func joinMatch(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Failed to read request body: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var joinData struct {
+		Code string `json:"code"`
+	}
+	err = json.Unmarshal(body, &joinData)
+	if err != nil || joinData.Code == "" {
+		http.Error(w, "Not a valid json body. Need key 'code'", http.StatusBadRequest)
+		return
+	}
+
+	_, err = c.JoinMatch(joinData.Code)
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+	writeMatch(w, c)
+}
+
+// writeMatch writes the scoreboard of the match of c to w.
+func writeMatch(w http.ResponseWriter, c *quiz.Connection) {
+	scoreboard, ok := c.GetMatch()
+	if !ok {
+		http.Error(w, "not in a match", http.StatusNotFound)
+		return
+	}
+
+	b, err := json.Marshal(scoreboard)
+	if err != nil {
+		log.Printf("Failed to marshal match scoreboard: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
@@ -0,0 +1,107 @@
+package webserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// handlePresets lists (GET) or creates (POST) the game presets of the user.
+func handlePresets(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var response any
+	switch r.Method {
+	case http.MethodGet:
+		presets, err := c.Presets()
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		response = presets
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("Failed to read request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		preset, err := c.SavePreset("", body)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		response = preset
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Failed to marshal presets: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
+
+// handlePreset gets (GET), replaces (PUT) or deletes (DELETE) a single game preset of the user.
+func handlePreset(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	presetID := mux.Vars(r)["id"]
+
+	var response any
+	switch r.Method {
+	case http.MethodGet:
+		preset, err := c.GetPreset(presetID)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		response = preset
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("Failed to read request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		preset, err := c.SavePreset(presetID, body)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		response = preset
+	case http.MethodDelete:
+		err := c.DeletePreset(presetID)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Failed to marshal preset: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
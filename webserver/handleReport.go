@@ -0,0 +1,148 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"quiz_backend/quiz"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// reportRound reports the question of the current round on behalf of the streamer. The body may
+// contain a "comment".
+func reportRound(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if c.Game == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Failed to read request body: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var reportData struct {
+		Comment string `json:"comment"`
+	}
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &reportData)
+		if err != nil {
+			http.Error(w, "Not a valid json body. Optional key 'comment'", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := c.Game.Report(reportData.Comment)
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("Failed to marshal report: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// handleReports lists the reports of the moderation queue for editors. The query parameter "status"
+// filters by a comma separated list of statuses.
+func handleReports(w http.ResponseWriter, r *http.Request) {
+	if !isEditor(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var statuses []quiz.ReportStatus
+	if query := r.URL.Query().Get("status"); query != "" {
+		for _, s := range strings.Split(query, ",") {
+			status, ok := quiz.ParseReportStatus(strings.TrimSpace(s))
+			if !ok {
+				http.Error(w, fmt.Sprintf("unknown report status '%s'", s), http.StatusBadRequest)
+				return
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	reports, err := quiz.Reports(statuses...)
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(reports)
+	if err != nil {
+		log.Printf("Failed to marshal reports: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
+
+// handleReport gets (GET) or triages and resolves (PUT) a single report for editors. The body of
+// PUT needs the new "status" and may contain a "note".
+func handleReport(w http.ResponseWriter, r *http.Request) {
+	if !isEditor(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	reportID := mux.Vars(r)["id"]
+
+	var report quiz.Report
+	switch r.Method {
+	case http.MethodGet:
+		var err error
+		report, err = quiz.GetReport(reportID)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("Failed to read request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var updateData struct {
+			Status *quiz.ReportStatus `json:"status"`
+			Note   string             `json:"note"`
+		}
+		err = json.Unmarshal(body, &updateData)
+		if err != nil || updateData.Status == nil {
+			http.Error(w, "Not a valid json body. Need key 'status'", http.StatusBadRequest)
+			return
+		}
+		report, err = quiz.UpdateReport(reportID, *updateData.Status, updateData.Note)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("Failed to marshal report: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
@@ -0,0 +1,122 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"quiz_backend/quiz"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// submitQuestion submits a new question of the user for review.
+func submitQuestion(w http.ResponseWriter, r *http.Request) {
+	c, ok := isAuthorized(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Failed to read request body: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	submission, err := c.Submit(body)
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(submission)
+	if err != nil {
+		log.Printf("Failed to marshal submission: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// handleSubmissions lists the submissions of the review queue for editors. The query parameter
+// "status" filters by a comma separated list of statuses.
+func handleSubmissions(w http.ResponseWriter, r *http.Request) {
+	if !isEditor(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var statuses []quiz.SubmissionStatus
+	if query := r.URL.Query().Get("status"); query != "" {
+		for _, s := range strings.Split(query, ",") {
+			status, ok := quiz.ParseSubmissionStatus(strings.TrimSpace(s))
+			if !ok {
+				http.Error(w, fmt.Sprintf("unknown submission status '%s'", s), http.StatusBadRequest)
+				return
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	submissions, err := quiz.Submissions(statuses...)
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(submissions)
+	if err != nil {
+		log.Printf("Failed to marshal submissions: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
+
+// handleSubmission gets (GET) or reviews (PUT) a single submission for editors. The body of PUT is
+// passed to [quiz.ReviewSubmission].
+func handleSubmission(w http.ResponseWriter, r *http.Request) {
+	if !isEditor(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	submissionID := mux.Vars(r)["id"]
+
+	var submission quiz.Submission
+	switch r.Method {
+	case http.MethodGet:
+		var err error
+		submission, err = quiz.GetSubmission(submissionID)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("Failed to read request body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		submission, err = quiz.ReviewSubmission(submissionID, body)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	b, err := json.Marshal(submission)
+	if err != nil {
+		log.Printf("Failed to marshal submission: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
@@ -1,6 +1,8 @@
 package webserver
 
 import (
+	"encoding/json"
+	"fmt"
 	"quiz_backend/quiz"
 	"time"
 
@@ -26,11 +28,75 @@ func wsRead(c *quiz.Connection) {
 		}
 
 		log.Printf("=> '%x': %s", mt, string(buf))
+		if mt == websocket.TextMessage && handleWSCommand(c, buf) {
+			continue
+		}
 		c.WS.WriteMessage(mt, append([]byte("Me can that too: "), buf...))
 	}
 
 }
 
+// handleWSCommand executes a command sent by the client over the websocket. It returns false if buf
+// is not a known command.
+func handleWSCommand(c *quiz.Connection, buf []byte) bool {
+	var command struct {
+		Type       string `json:"type"`
+		Seconds    int    `json:"seconds"`
+		ClientTime int64  `json:"client_time"`
+	}
+	if err := json.Unmarshal(buf, &command); err != nil {
+		return false
+	}
+
+	if command.Type == "TIME_SYNC" {
+		timeSync(c, command.ClientTime)
+		return true
+	}
+
+	var action func(g *quiz.Game) error
+	switch command.Type {
+	case "PAUSE":
+		action = (*quiz.Game).Pause
+	case "RESUME":
+		action = (*quiz.Game).Resume
+	case "ADD_TIME":
+		action = func(g *quiz.Game) error {
+			return g.AddTime(time.Duration(command.Seconds) * time.Second)
+		}
+	case "END_ROUND":
+		action = (*quiz.Game).EndRound
+	default:
+		return false
+	}
+
+	var err error
+	if c.Game == nil {
+		err = fmt.Errorf("no active game")
+	} else {
+		err = action(c.Game)
+	}
+	if err != nil {
+		wsError(c, command.Type, err)
+	}
+	return true
+}
+
+// wsError sends an error caused by a websocket command back to the client.
+func wsError(c *quiz.Connection, command string, err error) {
+	err = c.WriteJSON(struct {
+		Type    string `json:"type"`
+		Command string `json:"command"`
+		Message string `json:"message"`
+	}{
+		Type:    "ERROR",
+		Command: command,
+		Message: err.Error(),
+	})
+	if err != nil {
+		log.Printf("Error writing error to websocket: %v", err)
+	}
+}
+
 func keepAlive(c *quiz.Connection) {
 	c.SetLastResponse()
 	c.WS.SetPongHandler(func(appData string) error {
@@ -55,3 +121,23 @@ func keepAlive(c *quiz.Connection) {
 		}
 	}
 }
+
+// timeSync answers a time sync request of the client. The client sends its local time in unix
+// milliseconds and gets it back together with the server time. With the round trip time the client
+// can then estimate the offset of its clock to the server clock:
+//
+//	offset = server_time - (client_time + now) / 2
+func timeSync(c *quiz.Connection, clientTime int64) {
+	err := c.WriteJSON(struct {
+		Type       string `json:"type"`
+		ClientTime int64  `json:"client_time"`
+		ServerTime int64  `json:"server_time"`
+	}{
+		Type:       "TIME_SYNC",
+		ClientTime: clientTime,
+		ServerTime: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		log.Printf("Error writing time sync to websocket: %v", err)
+	}
+}
@@ -68,11 +68,34 @@ func initHandler() http.Handler {
 	r.HandleFunc("/chat", handleChat).Methods(http.MethodGet)
 
 	r.HandleFunc("/category", handleCategory).Methods(http.MethodGet)
+	r.HandleFunc("/history", resetHistory).Methods(http.MethodDelete)
+	r.HandleFunc("/settings", handleSettings).Methods(http.MethodGet, http.MethodPut)
 
 	r.HandleFunc("/game", handleGame)
+	r.HandleFunc("/preset", handlePresets).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/preset/{id}", handlePreset).Methods(http.MethodGet, http.MethodPut, http.MethodDelete)
+	r.HandleFunc("/report", handleReports).Methods(http.MethodGet)
+	r.HandleFunc("/report/{id}", handleReport).Methods(http.MethodGet, http.MethodPut)
+	r.HandleFunc("/submission", submitQuestion).Methods(http.MethodPost)
+	r.HandleFunc("/submission", handleSubmissions).Methods(http.MethodGet)
+	r.HandleFunc("/submission/{id}", handleSubmission).Methods(http.MethodGet, http.MethodPut)
+	r.HandleFunc("/match", handleMatch).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+	r.HandleFunc("/match/join", joinMatch).Methods(http.MethodPost)
 	r.HandleFunc("/vote/streamer", handleStreamerVote).Methods(http.MethodPost)
+	r.HandleFunc("/joker/fifty_fifty", handleJoker((*quiz.Game).UseFiftyFifty)).Methods(http.MethodPost)
+	r.HandleFunc("/joker/ask_chat", handleJoker((*quiz.Game).UseAskChat)).Methods(http.MethodPost)
+	r.HandleFunc("/joker/swap", handleJoker((*quiz.Game).UseSwap)).Methods(http.MethodPost)
 	r.HandleFunc("/round", getRound).Methods(http.MethodGet)
 	r.HandleFunc("/round/next", nextRound).Methods(http.MethodPost)
+	r.HandleFunc("/round/reveal", revealRound).Methods(http.MethodPost)
+	r.HandleFunc("/round/pause", handleRoundAction((*quiz.Game).Pause)).Methods(http.MethodPost)
+	r.HandleFunc("/round/resume", handleRoundAction((*quiz.Game).Resume)).Methods(http.MethodPost)
+	r.HandleFunc("/round/extend", extendRound).Methods(http.MethodPost)
+	r.HandleFunc("/round/end", handleRoundAction((*quiz.Game).EndRound)).Methods(http.MethodPost)
+	r.HandleFunc("/round/report", reportRound).Methods(http.MethodPost)
+	r.HandleFunc("/round/void", handleCorrection(quiz.CORRECTIONVOID)).Methods(http.MethodPost)
+	r.HandleFunc("/round/reroll", handleCorrection(quiz.CORRECTIONREROLL)).Methods(http.MethodPost)
+	r.HandleFunc("/round/correct", handleCorrection(quiz.CORRECTIONANSWER)).Methods(http.MethodPost)
 
 	return r
 }
@@ -86,6 +109,13 @@ func handle404(w http.ResponseWriter, r *http.Request) {
 // activeAuth is a map from temporary tokens to a user id.
 var activeAuth = make(map[string]string)
 
+// isEditor reports whether r is sent by an editor of the questions. Editors authenticate with the
+// webserver password, like when fetching the questions.
+func isEditor(r *http.Request) bool {
+	password, found := strings.CutPrefix(r.Header.Get("Authorization"), "Editor ")
+	return found && password != "" && password == viper.GetString("webserver.password")
+}
+
 func isAuthorized(r *http.Request) (c *quiz.Connection, ok bool) {
 	token := r.Header.Get("Authorization")
 	token, found := strings.CutPrefix(token, "Q4E ")